@@ -0,0 +1,71 @@
+package common
+
+import (
+	"fmt"
+	"sync"
+)
+
+// AssetRegistry is an in-memory lookup of asset metadata by ID and symbol.
+// It is safe for concurrent use.
+type AssetRegistry struct {
+	mu       sync.RWMutex
+	byID     map[Hash]Asset
+	bySymbol map[string]Hash
+}
+
+// NewAssetRegistry returns a registry containing SystemAsset.
+func NewAssetRegistry() *AssetRegistry {
+	r := &AssetRegistry{
+		byID:     make(map[Hash]Asset),
+		bySymbol: make(map[string]Hash),
+	}
+	r.Register(SystemAsset)
+	return r
+}
+
+// Register adds the asset to the registry, replacing any asset with the same
+// ID. Symbols are not unique, BySymbol returns the asset registered last.
+func (r *AssetRegistry) Register(asset Asset) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if old, ok := r.byID[asset.ID]; ok && r.bySymbol[old.Symbol] == asset.ID {
+		delete(r.bySymbol, old.Symbol)
+	}
+	r.byID[asset.ID] = asset.DeepCopy()
+	r.bySymbol[asset.Symbol] = asset.ID
+}
+
+// Get returns the asset with the given ID.
+func (r *AssetRegistry) Get(id Hash) (Asset, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	asset, ok := r.byID[id]
+	if !ok {
+		return Asset{}, false
+	}
+	return asset.DeepCopy(), true
+}
+
+// BySymbol returns the asset last registered with the given symbol.
+func (r *AssetRegistry) BySymbol(symbol string) (Asset, bool) {
+	r.mu.RLock()
+	id, ok := r.bySymbol[symbol]
+	r.mu.RUnlock()
+
+	if !ok {
+		return Asset{}, false
+	}
+	return r.Get(id)
+}
+
+// GenerateID returns AssetID(creator, nonce), failing if an asset with that
+// ID is already registered.
+func (r *AssetRegistry) GenerateID(creator Address, nonce uint64) (Hash, error) {
+	id := AssetID(creator, nonce)
+	if _, exist := r.Get(id); exist {
+		return Hash{}, fmt.Errorf("asset %x already exists for creator %x and nonce %d", id, creator, nonce)
+	}
+	return id, nil
+}
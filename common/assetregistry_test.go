@@ -0,0 +1,68 @@
+package common
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestAssetRegistry(t *testing.T) {
+	r := NewAssetRegistry()
+	if asset, ok := r.Get(SystemAssetID); !ok || asset.Symbol != "FSN" {
+		t.Fatalf("SystemAsset not registered by default: %+v", asset)
+	}
+	if asset, ok := r.BySymbol("FSN"); !ok || asset.ID != SystemAssetID {
+		t.Fatalf("SystemAsset not found by symbol: %+v", asset)
+	}
+
+	asset := Asset{ID: HexToHash("0x01"), Name: "Test Asset", Symbol: "TST", Decimals: 2, Total: big.NewInt(100)}
+	r.Register(asset)
+	got, ok := r.Get(asset.ID)
+	if !ok || got.Name != asset.Name || got.Total.Cmp(asset.Total) != 0 {
+		t.Errorf("Get(%x) = %+v, %v", asset.ID, got, ok)
+	}
+	if got, ok := r.BySymbol("TST"); !ok || got.ID != asset.ID {
+		t.Errorf("BySymbol(TST) = %+v, %v", got, ok)
+	}
+	// returned assets do not alias the registry
+	got.Total.SetInt64(0)
+	if got, _ := r.Get(asset.ID); got.Total.Int64() != 100 {
+		t.Errorf("registry modified through returned asset: %v", got.Total)
+	}
+
+	// re-registering with a new symbol drops the old one
+	asset.Symbol = "TST2"
+	r.Register(asset)
+	if _, ok := r.BySymbol("TST"); ok {
+		t.Error("stale symbol still registered")
+	}
+	if got, ok := r.BySymbol("TST2"); !ok || got.ID != asset.ID {
+		t.Errorf("BySymbol(TST2) = %+v, %v", got, ok)
+	}
+	if _, ok := r.Get(HexToHash("0x02")); ok {
+		t.Error("unexpected asset for unknown ID")
+	}
+	if _, ok := r.BySymbol("NONE"); ok {
+		t.Error("unexpected asset for unknown symbol")
+	}
+}
+
+func TestAssetRegistryGenerateID(t *testing.T) {
+	r := NewAssetRegistry()
+	creator := HexToAddress("0x01")
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		id, err := r.GenerateID(creator, nonce)
+		if err != nil {
+			t.Fatalf("nonce %d: unexpected error: %v", nonce, err)
+		}
+		if id != AssetID(creator, nonce) {
+			t.Errorf("nonce %d: have ID %x, want %x", nonce, id, AssetID(creator, nonce))
+		}
+		r.Register(Asset{ID: id, Symbol: "TST", Owner: creator, Total: big.NewInt(1)})
+	}
+	if _, err := r.GenerateID(creator, 1); err == nil {
+		t.Error("expected collision error for reused nonce")
+	}
+	if _, err := r.GenerateID(HexToAddress("0x02"), 1); err != nil {
+		t.Errorf("unexpected error for other creator: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+// bech32 encoding as specified in BIP173.
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+var bech32Generator = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+func bech32Polymod(values []byte) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= bech32Generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	exp := make([]byte, 0, 2*len(hrp)+1)
+	for i := 0; i < len(hrp); i++ {
+		exp = append(exp, hrp[i]>>5)
+	}
+	exp = append(exp, 0)
+	for i := 0; i < len(hrp); i++ {
+		exp = append(exp, hrp[i]&31)
+	}
+	return exp
+}
+
+func bech32Checksum(hrp string, data []byte) []byte {
+	values := append(bech32HRPExpand(hrp), data...)
+	values = append(values, 0, 0, 0, 0, 0, 0)
+	mod := bech32Polymod(values) ^ 1
+	checksum := make([]byte, 6)
+	for i := range checksum {
+		checksum[i] = byte(mod>>uint(5*(5-i))) & 31
+	}
+	return checksum
+}
+
+// convertBits regroups data from fromBits to toBits wide values.
+func convertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, error) {
+	var (
+		acc    uint32
+		bits   uint
+		out    []byte
+		maxv   = uint32(1)<<toBits - 1
+		maxAcc = uint32(1)<<(fromBits+toBits-1) - 1
+	)
+	for _, v := range data {
+		if uint32(v)>>fromBits != 0 {
+			return nil, fmt.Errorf("invalid data range: %d", v)
+		}
+		acc = (acc<<fromBits | uint32(v)) & maxAcc
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(toBits-bits)&maxv))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxv != 0 {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	return out, nil
+}
+
+// bech32Encode encodes the 5 bit data with the human-readable part hrp.
+func bech32Encode(hrp string, data []byte) (string, error) {
+	if len(hrp) == 0 || len(hrp) > 83 {
+		return "", fmt.Errorf("invalid bech32 human-readable part length %d", len(hrp))
+	}
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", fmt.Errorf("invalid bech32 human-readable part character %q", hrp[i])
+		}
+	}
+	hrp = strings.ToLower(hrp)
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, v := range append(data, bech32Checksum(hrp, data)...) {
+		sb.WriteByte(bech32Charset[v])
+	}
+	if sb.Len() > 90 {
+		return "", fmt.Errorf("bech32 string too long")
+	}
+	return sb.String(), nil
+}
+
+// bech32Decode decodes s into its human-readable part and 5 bit data,
+// verifying the checksum.
+func bech32Decode(s string) (string, []byte, error) {
+	if len(s) < 8 || len(s) > 90 {
+		return "", nil, fmt.Errorf("invalid bech32 string length %d", len(s))
+	}
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, fmt.Errorf("mixed case bech32 string")
+	}
+	s = strings.ToLower(s)
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 || sep+7 > len(s) {
+		return "", nil, fmt.Errorf("invalid bech32 separator position")
+	}
+	hrp := s[:sep]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, fmt.Errorf("invalid bech32 human-readable part character %q", hrp[i])
+		}
+	}
+	data := make([]byte, 0, len(s)-sep-1)
+	for i := sep + 1; i < len(s); i++ {
+		v := strings.IndexByte(bech32Charset, s[i])
+		if v < 0 {
+			return "", nil, fmt.Errorf("invalid bech32 character %q", s[i])
+		}
+		data = append(data, byte(v))
+	}
+	if bech32Polymod(append(bech32HRPExpand(hrp), data...)) != 1 {
+		return "", nil, fmt.Errorf("invalid bech32 checksum")
+	}
+	return hrp, data[:len(data)-6], nil
+}
+
+// Bech32 returns the bech32 encoding of the address with the given
+// human-readable part.
+func (a Address) Bech32(hrp string) (string, error) {
+	data, err := convertBits(a[:], 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	return bech32Encode(hrp, data)
+}
+
+// ParseBech32Address decodes a bech32 encoded address and returns it along
+// with its human-readable part.
+func ParseBech32Address(s string) (Address, string, error) {
+	hrp, data, err := bech32Decode(s)
+	if err != nil {
+		return Address{}, "", err
+	}
+	b, err := convertBits(data, 5, 8, false)
+	if err != nil {
+		return Address{}, "", err
+	}
+	if len(b) != AddressLength {
+		return Address{}, "", fmt.Errorf("invalid bech32 address length %d", len(b))
+	}
+	return BytesToAddress(b), hrp, nil
+}
@@ -0,0 +1,84 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBech32Vectors(t *testing.T) {
+	// valid and invalid checksums from BIP173
+	valid := []string{
+		"A12UEL5L",
+		"a12uel5l",
+		"an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs",
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		"11" + strings.Repeat("q", 82) + "c8247j",
+		"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+	}
+	for _, s := range valid {
+		if _, _, err := bech32Decode(s); err != nil {
+			t.Errorf("%s: unexpected error: %v", s, err)
+		}
+	}
+	invalid := []string{
+		"\x201nwldj5",
+		"an84characterslonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1569pvx",
+		"pzry9x0s0muk",
+		"1pzry9x0s0muk",
+		"x1b4n0q5v",
+		"li1dgmt3",
+		"A1G7SGD8",
+		"10a06t8",
+		"1qzzfhee",
+	}
+	for _, s := range invalid {
+		if _, _, err := bech32Decode(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
+
+func TestAddressBech32(t *testing.T) {
+	addrs := []Address{
+		{},
+		HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"),
+		HexToAddress("0xffffffffffffffffffffffffffffffffffffffff"),
+	}
+	for _, addr := range addrs {
+		enc, err := addr.Bech32("fsn")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(enc, "fsn1") {
+			t.Errorf("%x: unexpected encoding %s", addr, enc)
+		}
+		dec, hrp, err := ParseBech32Address(enc)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", enc, err)
+		}
+		if dec != addr || hrp != "fsn" {
+			t.Errorf("%s: decoded %x with hrp %q, want %x with hrp fsn", enc, dec, hrp, addr)
+		}
+		if _, _, err := ParseBech32Address(strings.ToUpper(enc)); err != nil {
+			t.Errorf("%s: upper case decoding failed: %v", enc, err)
+		}
+	}
+
+	enc, _ := addrs[1].Bech32("fsn")
+	corrupted := []byte(enc)
+	if corrupted[10] == 'q' {
+		corrupted[10] = 'p'
+	} else {
+		corrupted[10] = 'q'
+	}
+	if _, _, err := ParseBech32Address(string(corrupted)); err == nil {
+		t.Errorf("%s: expected checksum error", corrupted)
+	}
+	// valid bech32 that does not hold 20 bytes
+	if _, _, err := ParseBech32Address("split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w"); err == nil {
+		t.Error("expected error decoding non-address payload")
+	}
+	if _, err := addrs[1].Bech32(""); err == nil {
+		t.Error("expected error for empty human-readable part")
+	}
+}
@@ -34,3 +34,42 @@ var (
 
 	BigMaxUint64 = new(big.Int).SetUint64(math.MaxUint64)
 )
+
+// bigCacheSize is the number of small non-negative integers cached by Big.
+const bigCacheSize = 1024
+
+var bigCache = func() (cache [bigCacheSize]*big.Int) {
+	for _, v := range []*big.Int{Big0, Big1, Big2, Big3, Big32, Big100, Big256, Big257} {
+		cache[v.Int64()] = v
+	}
+	for i := range cache {
+		if cache[i] == nil {
+			cache[i] = big.NewInt(int64(i))
+		}
+	}
+	return cache
+}()
+
+// Big returns n as a big integer. Values in [0, 1024) are shared cached
+// instances, like Big0 and Big1 they must never be modified; use
+// new(big.Int).Set to get a mutable copy. Other values are newly allocated.
+func Big(n int64) *big.Int {
+	if n >= 0 && n < bigCacheSize {
+		return bigCache[n]
+	}
+	return big.NewInt(n)
+}
+
+// copyBig returns a copy of v, or nil if v is nil.
+func copyBig(v *big.Int) *big.Int {
+	if v == nil {
+		return nil
+	}
+	return new(big.Int).Set(v)
+}
+
+// mulWithin256 returns a*b and whether the product fits in 256 bits.
+func mulWithin256(a, b *big.Int) (*big.Int, bool) {
+	product := new(big.Int).Mul(a, b)
+	return product, product.BitLen() <= 256
+}
@@ -0,0 +1,43 @@
+package common
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBig(t *testing.T) {
+	for _, n := range []int64{0, 1, 2, 100, 257, bigCacheSize - 1, bigCacheSize, -1, 1 << 40} {
+		if v := Big(n); !v.IsInt64() || v.Int64() != n {
+			t.Errorf("Big(%d) = %v", n, v)
+		}
+	}
+	if Big(0) != Big0 || Big(1) != Big1 || Big(256) != Big256 {
+		t.Error("Big does not return the predefined constants")
+	}
+	if Big(5) != Big(5) {
+		t.Error("cached value not shared")
+	}
+	if Big(bigCacheSize) == Big(bigCacheSize) || Big(-1) == Big(-1) {
+		t.Error("uncached values shared")
+	}
+}
+
+func BenchmarkBigCheck(b *testing.B) {
+	values := []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(18), big.NewInt(1000)}
+	b.Run("Big", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, v := range values {
+				_ = v.Cmp(Big(18)) > 0 && v.Cmp(Big(1000)) <= 0
+			}
+		}
+	})
+	b.Run("NewInt", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, v := range values {
+				_ = v.Cmp(big.NewInt(18)) > 0 && v.Cmp(big.NewInt(1000)) <= 0
+			}
+		}
+	})
+}
@@ -76,6 +76,13 @@ func IsSmartTransferEnabled(blockNumber *big.Int) bool {
 	return IsHardFork(2, blockNumber)
 }
 
+// IsStrictParamCheckingEnabled reports whether the stricter FSNCall param
+// validation rules apply. They are enforced for RPC calls (nil or max block
+// number) right away and on chain from the next scheduled hard fork.
+func IsStrictParamCheckingEnabled(blockNumber *big.Int) bool {
+	return IsHardFork(3, blockNumber)
+}
+
 func GetConstantinopleEnableHeight() *big.Int {
 	if UseDevnetRule {
 		return DevnetConstantinopleEnableHeight
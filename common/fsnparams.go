@@ -1,9 +1,17 @@
 package common
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/FusionFoundation/efsn/common/hexutil"
+	"github.com/FusionFoundation/efsn/crypto/sha3"
 	"github.com/FusionFoundation/efsn/rlp"
 )
 
@@ -14,100 +22,104 @@ type FSNCallParam struct {
 	Data []byte
 }
 
+// GenNotationParam is the param of GenNotationFunc, which takes no
+// arguments. Its encoding is empty.
+type GenNotationParam struct{}
+
 // GenAssetParam wacom
 type GenAssetParam struct {
-	Name        string
-	Symbol      string
-	Decimals    uint8
-	Total       *big.Int `json:",string"`
-	CanChange   bool
-	Description string
+	Name        string   `json:"name"`
+	Symbol      string   `json:"symbol"`
+	Decimals    uint8    `json:"decimals"`
+	Total       *big.Int `json:"total"`
+	CanChange   bool     `json:"canChange"`
+	Description string   `json:"description"`
 }
 
 // BuyTicketParam wacom
 type BuyTicketParam struct {
-	Start uint64
-	End   uint64
+	Start uint64 `json:"start"`
+	End   uint64 `json:"end"`
 }
 
 // SendAssetParam wacom
 type SendAssetParam struct {
-	AssetID Hash
-	To      Address
-	Value   *big.Int `json:",string"`
+	AssetID Hash     `json:"assetID"`
+	To      Address  `json:"to"`
+	Value   *big.Int `json:"value"`
 }
 
 // AssetValueChangeExParam wacom
 type AssetValueChangeExParam struct {
-	AssetID     Hash
-	To          Address
-	Value       *big.Int `json:",string"`
-	IsInc       bool
-	TransacData string
+	AssetID     Hash     `json:"assetID"`
+	To          Address  `json:"to"`
+	Value       *big.Int `json:"value"`
+	IsInc       bool     `json:"isInc"`
+	TransacData string   `json:"transacData"`
 }
 
 // TimeLockParam wacom
 type TimeLockParam struct {
-	Type      TimeLockType
-	AssetID   Hash
-	To        Address
-	StartTime uint64
-	EndTime   uint64
-	Value     *big.Int `json:",string"`
+	Type      TimeLockType `json:"type"`
+	AssetID   Hash         `json:"assetID"`
+	To        Address      `json:"to"`
+	StartTime uint64       `json:"startTime"`
+	EndTime   uint64       `json:"endTime"`
+	Value     *big.Int     `json:"value"`
 }
 
 // MakeSwapParam wacom
 type MakeSwapParam struct {
-	FromAssetID   Hash
-	FromStartTime uint64
-	FromEndTime   uint64
-	MinFromAmount *big.Int `json:",string"`
-	ToAssetID     Hash
-	ToStartTime   uint64
-	ToEndTime     uint64
-	MinToAmount   *big.Int `json:",string"`
-	SwapSize      *big.Int `json:",string"`
-	Targes        []Address
-	Time          *big.Int
-	Description   string
+	FromAssetID   Hash      `json:"fromAssetID"`
+	FromStartTime uint64    `json:"fromStartTime"`
+	FromEndTime   uint64    `json:"fromEndTime"`
+	MinFromAmount *big.Int  `json:"minFromAmount"`
+	ToAssetID     Hash      `json:"toAssetID"`
+	ToStartTime   uint64    `json:"toStartTime"`
+	ToEndTime     uint64    `json:"toEndTime"`
+	MinToAmount   *big.Int  `json:"minToAmount"`
+	SwapSize      *big.Int  `json:"swapSize"`
+	Targes        []Address `json:"targes"`
+	Time          *big.Int  `json:"time"`
+	Description   string    `json:"description"`
 }
 
 // MakeMultiSwapParam wacom
 type MakeMultiSwapParam struct {
-	FromAssetID   []Hash
-	FromStartTime []uint64
-	FromEndTime   []uint64
-	MinFromAmount []*big.Int `json:",string"`
-	ToAssetID     []Hash
-	ToStartTime   []uint64
-	ToEndTime     []uint64
-	MinToAmount   []*big.Int `json:",string"`
-	SwapSize      *big.Int   `json:",string"`
-	Targes        []Address
-	Time          *big.Int
-	Description   string
+	FromAssetID   []Hash     `json:"fromAssetID"`
+	FromStartTime []uint64   `json:"fromStartTime"`
+	FromEndTime   []uint64   `json:"fromEndTime"`
+	MinFromAmount []*big.Int `json:"minFromAmount"`
+	ToAssetID     []Hash     `json:"toAssetID"`
+	ToStartTime   []uint64   `json:"toStartTime"`
+	ToEndTime     []uint64   `json:"toEndTime"`
+	MinToAmount   []*big.Int `json:"minToAmount"`
+	SwapSize      *big.Int   `json:"swapSize"`
+	Targes        []Address  `json:"targes"`
+	Time          *big.Int   `json:"time"`
+	Description   string     `json:"description"`
 }
 
 // RecallSwapParam wacom
 type RecallSwapParam struct {
-	SwapID Hash
+	SwapID Hash `json:"swapID"`
 }
 
 // RecallMultiSwapParam wacom
 type RecallMultiSwapParam struct {
-	SwapID Hash
+	SwapID Hash `json:"swapID"`
 }
 
 // TakeSwapParam wacom
 type TakeSwapParam struct {
-	SwapID Hash
-	Size   *big.Int `json:",string"`
+	SwapID Hash     `json:"swapID"`
+	Size   *big.Int `json:"size"`
 }
 
 // TakeMultiSwapParam wacom
 type TakeMultiSwapParam struct {
-	SwapID Hash
-	Size   *big.Int `json:",string"`
+	SwapID Hash     `json:"swapID"`
+	Size   *big.Int `json:"size"`
 }
 
 /////////////////// param ToBytes ///////////////////////
@@ -116,61 +128,417 @@ func (p *FSNCallParam) ToBytes() ([]byte, error) {
 	return rlp.EncodeToBytes(p)
 }
 
+// EncodeTo appends the ToBytes encoding of p to buf.
+func (p *FSNCallParam) EncodeTo(buf *bytes.Buffer) error {
+	return rlp.Encode(buf, p)
+}
+
+// ToBytes wacom
+func (p *GenNotationParam) ToBytes() ([]byte, error) {
+	return nil, nil
+}
+
+// EncodeTo wacom
+func (p *GenNotationParam) EncodeTo(buf *bytes.Buffer) error {
+	return nil
+}
+
 // ToBytes wacom
 func (p *GenAssetParam) ToBytes() ([]byte, error) {
 	return rlp.EncodeToBytes(p)
 }
 
+// EncodeTo appends the ToBytes encoding of p to buf.
+func (p *GenAssetParam) EncodeTo(buf *bytes.Buffer) error {
+	return rlp.Encode(buf, p)
+}
+
 // ToBytes wacom
 func (p *SendAssetParam) ToBytes() ([]byte, error) {
 	return rlp.EncodeToBytes(p)
 }
 
+// EncodeTo appends the ToBytes encoding of p to buf.
+func (p *SendAssetParam) EncodeTo(buf *bytes.Buffer) error {
+	return rlp.Encode(buf, p)
+}
+
 // ToBytes wacom
 func (p *TimeLockParam) ToBytes() ([]byte, error) {
 	return rlp.EncodeToBytes(p)
 }
 
+// EncodeTo appends the ToBytes encoding of p to buf.
+func (p *TimeLockParam) EncodeTo(buf *bytes.Buffer) error {
+	return rlp.Encode(buf, p)
+}
+
 // ToBytes wacom
 func (p *BuyTicketParam) ToBytes() ([]byte, error) {
 	return rlp.EncodeToBytes(p)
 }
 
+// EncodeTo appends the ToBytes encoding of p to buf.
+func (p *BuyTicketParam) EncodeTo(buf *bytes.Buffer) error {
+	return rlp.Encode(buf, p)
+}
+
 // ToBytes wacom
 func (p *AssetValueChangeExParam) ToBytes() ([]byte, error) {
 	return rlp.EncodeToBytes(p)
 }
 
+// EncodeTo appends the ToBytes encoding of p to buf.
+func (p *AssetValueChangeExParam) EncodeTo(buf *bytes.Buffer) error {
+	return rlp.Encode(buf, p)
+}
+
+// TransacBytes returns TransacData as raw bytes.
+func (p *AssetValueChangeExParam) TransacBytes() []byte {
+	return []byte(p.TransacData)
+}
+
+// SetTransacBytes sets TransacData from raw bytes.
+func (p *AssetValueChangeExParam) SetTransacBytes(data []byte) {
+	p.TransacData = string(data)
+}
+
 // ToBytes wacom
 func (p *MakeSwapParam) ToBytes() ([]byte, error) {
 	return rlp.EncodeToBytes(p)
 }
 
+// EncodeTo appends the ToBytes encoding of p to buf.
+func (p *MakeSwapParam) EncodeTo(buf *bytes.Buffer) error {
+	return rlp.Encode(buf, p)
+}
+
 // ToBytes wacom
 func (p *RecallSwapParam) ToBytes() ([]byte, error) {
 	return rlp.EncodeToBytes(p)
 }
 
+// EncodeTo appends the ToBytes encoding of p to buf.
+func (p *RecallSwapParam) EncodeTo(buf *bytes.Buffer) error {
+	return rlp.Encode(buf, p)
+}
+
 // ToBytes wacom
 func (p *TakeSwapParam) ToBytes() ([]byte, error) {
 	return rlp.EncodeToBytes(p)
 }
 
+// EncodeTo appends the ToBytes encoding of p to buf.
+func (p *TakeSwapParam) EncodeTo(buf *bytes.Buffer) error {
+	return rlp.Encode(buf, p)
+}
+
 // ToBytes wacom
 func (p *MakeMultiSwapParam) ToBytes() ([]byte, error) {
 	return rlp.EncodeToBytes(p)
 }
 
+// EncodeTo appends the ToBytes encoding of p to buf.
+func (p *MakeMultiSwapParam) EncodeTo(buf *bytes.Buffer) error {
+	return rlp.Encode(buf, p)
+}
+
 // ToBytes wacom
 func (p *RecallMultiSwapParam) ToBytes() ([]byte, error) {
 	return rlp.EncodeToBytes(p)
 }
 
+// EncodeTo appends the ToBytes encoding of p to buf.
+func (p *RecallMultiSwapParam) EncodeTo(buf *bytes.Buffer) error {
+	return rlp.Encode(buf, p)
+}
+
 // ToBytes wacom
 func (p *TakeMultiSwapParam) ToBytes() ([]byte, error) {
 	return rlp.EncodeToBytes(p)
 }
 
+// EncodeTo appends the ToBytes encoding of p to buf.
+func (p *TakeMultiSwapParam) EncodeTo(buf *bytes.Buffer) error {
+	return rlp.Encode(buf, p)
+}
+
+/////////////////// param decoding ///////////////////////
+// DecodeFSNCallParam decodes an RLP encoded FSNCallParam, the inverse of
+// FSNCallParam.ToBytes.
+func DecodeFSNCallParam(data []byte) (*FSNCallParam, error) {
+	var p FSNCallParam
+	if err := rlp.DecodeBytes(data, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// MaxFSNCallParamVersion is the newest FSNCallParam encoding version.
+// Version 0 is the plain RLP encoding produced by ToBytes, later versions
+// prefix it with the version byte. As RLP lists start with a byte >= 0xc0,
+// the prefix can not be mistaken for a version 0 encoding.
+const MaxFSNCallParamVersion = 1
+
+// ToBytesV returns the encoding of p in the given version.
+func (p *FSNCallParam) ToBytesV(version uint8) ([]byte, error) {
+	if version > MaxFSNCallParamVersion {
+		return nil, fmt.Errorf("unsupported FSNCallParam version %d", version)
+	}
+	data, err := p.ToBytes()
+	if err != nil || version == 0 {
+		return data, err
+	}
+	return append([]byte{version}, data...), nil
+}
+
+// DecodeFSNCallParamV decodes an FSNCallParam encoded by ToBytesV and
+// returns it along with its encoding version.
+func DecodeFSNCallParamV(data []byte) (*FSNCallParam, uint8, error) {
+	var version uint8
+	if len(data) > 0 && data[0] < 0xc0 {
+		version, data = data[0], data[1:]
+		if version == 0 || version > MaxFSNCallParamVersion {
+			return nil, 0, fmt.Errorf("unsupported FSNCallParam version %d", version)
+		}
+	}
+	p, err := DecodeFSNCallParam(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	return p, version, nil
+}
+
+// DecodeData decodes the RLP encoded Data of the call into out, which must
+// be a pointer to the param type matching p.Func.
+func (p *FSNCallParam) DecodeData(out interface{}) error {
+	return rlp.DecodeBytes(p.Data, out)
+}
+
+// DecodeTyped decodes the Data of the call into the param type matching
+// p.Func and returns a pointer to it, e.g. *GenAssetParam for GenAssetFunc.
+func (p *FSNCallParam) DecodeTyped() (interface{}, error) {
+	var param interface{}
+	switch p.Func {
+	case GenNotationFunc:
+		if len(p.Data) == 0 {
+			return &GenNotationParam{}, nil
+		}
+		param = &GenNotationParam{}
+	case GenAssetFunc:
+		param = &GenAssetParam{}
+	case SendAssetFunc:
+		param = &SendAssetParam{}
+	case TimeLockFunc:
+		param = &TimeLockParam{}
+	case BuyTicketFunc:
+		param = &BuyTicketParam{}
+	case AssetValueChangeFunc:
+		param = &AssetValueChangeExParam{}
+	case MakeSwapFunc, MakeSwapFuncExt:
+		param = &MakeSwapParam{}
+	case RecallSwapFunc:
+		param = &RecallSwapParam{}
+	case TakeSwapFunc, TakeSwapFuncExt:
+		param = &TakeSwapParam{}
+	case MakeMultiSwapFunc:
+		param = &MakeMultiSwapParam{}
+	case RecallMultiSwapFunc:
+		param = &RecallMultiSwapParam{}
+	case TakeMultiSwapFunc:
+		param = &TakeMultiSwapParam{}
+	case EmptyFunc:
+		return nil, fmt.Errorf("EmptyFunc carries no param")
+	default:
+		return nil, fmt.Errorf("can not decode param of FuncType %v (%v)", uint8(p.Func), p.Func.Name())
+	}
+	if err := p.DecodeData(param); err != nil {
+		return nil, fmt.Errorf("decode %v param err %v", p.Func.Name(), err)
+	}
+	return param, nil
+}
+
+/////////////////// param EncodingHash ///////////////////////
+func rlpHash(x interface{}) (h Hash) {
+	hw := sha3.NewKeccak256()
+	rlp.Encode(hw, x)
+	hw.Sum(h[:0])
+	return h
+}
+
+// EncodingHash returns the Keccak256 hash of the RLP encoding.
+func (p *FSNCallParam) EncodingHash() Hash {
+	return rlpHash(p)
+}
+
+// EncodingHash returns the Keccak256 hash of the RLP encoding.
+func (p *GenAssetParam) EncodingHash() Hash {
+	return rlpHash(p)
+}
+
+// EncodingHash returns the Keccak256 hash of the RLP encoding.
+func (p *SendAssetParam) EncodingHash() Hash {
+	return rlpHash(p)
+}
+
+// EncodingHash returns the Keccak256 hash of the RLP encoding.
+func (p *TimeLockParam) EncodingHash() Hash {
+	return rlpHash(p)
+}
+
+// EncodingHash returns the Keccak256 hash of the RLP encoding.
+func (p *BuyTicketParam) EncodingHash() Hash {
+	return rlpHash(p)
+}
+
+// EncodingHash returns the Keccak256 hash of the RLP encoding.
+func (p *AssetValueChangeExParam) EncodingHash() Hash {
+	return rlpHash(p)
+}
+
+// EncodingHash returns the Keccak256 hash of the RLP encoding.
+func (p *MakeSwapParam) EncodingHash() Hash {
+	return rlpHash(p)
+}
+
+// EncodingHash returns the Keccak256 hash of the RLP encoding.
+func (p *RecallSwapParam) EncodingHash() Hash {
+	return rlpHash(p)
+}
+
+// EncodingHash returns the Keccak256 hash of the RLP encoding.
+func (p *TakeSwapParam) EncodingHash() Hash {
+	return rlpHash(p)
+}
+
+// EncodingHash returns the Keccak256 hash of the RLP encoding.
+func (p *MakeMultiSwapParam) EncodingHash() Hash {
+	return rlpHash(p)
+}
+
+// EncodingHash returns the Keccak256 hash of the RLP encoding.
+func (p *RecallMultiSwapParam) EncodingHash() Hash {
+	return rlpHash(p)
+}
+
+// EncodingHash returns the Keccak256 hash of the RLP encoding.
+func (p *TakeMultiSwapParam) EncodingHash() Hash {
+	return rlpHash(p)
+}
+
+// MarshalJSON encodes the call with its Data decoded according to Func.
+// Data that can not be decoded is emitted as hex instead.
+func (p *FSNCallParam) MarshalJSON() ([]byte, error) {
+	enc := struct {
+		Func   string        `json:"func"`
+		Params interface{}   `json:"params,omitempty"`
+		Data   hexutil.Bytes `json:"data,omitempty"`
+	}{
+		Func: p.Func.String(),
+	}
+	if param, err := p.DecodeTyped(); err == nil {
+		enc.Params = param
+	} else {
+		enc.Data = p.Data
+	}
+	return json.Marshal(&enc)
+}
+
+// ToSwap returns the swap described by p with its ID and Owner set. The
+// returned swap shares no memory with p.
+func (p *MakeSwapParam) ToSwap(id Hash, owner Address) Swap {
+	var targes []Address
+	if p.Targes != nil {
+		targes = make([]Address, len(p.Targes))
+		copy(targes, p.Targes)
+	}
+	return Swap{
+		ID:            id,
+		Owner:         owner,
+		FromAssetID:   p.FromAssetID,
+		FromStartTime: p.FromStartTime,
+		FromEndTime:   p.FromEndTime,
+		MinFromAmount: copyBig(p.MinFromAmount),
+		ToAssetID:     p.ToAssetID,
+		ToStartTime:   p.ToStartTime,
+		ToEndTime:     p.ToEndTime,
+		MinToAmount:   copyBig(p.MinToAmount),
+		SwapSize:      copyBig(p.SwapSize),
+		Targes:        targes,
+		Time:          copyBig(p.Time), // this will mean the block time
+		Description:   p.Description,
+	}
+}
+
+// NormalizeTargets removes zero addresses and duplicates from p.Targes,
+// keeping the first occurrence of each target.
+func (p *MakeSwapParam) NormalizeTargets() {
+	if len(p.Targes) == 0 {
+		return
+	}
+	targes := DedupAddresses(p.Targes)
+	p.Targes = targes[:0]
+	for _, target := range targes {
+		if !target.IsZero() {
+			p.Targes = append(p.Targes, target)
+		}
+	}
+}
+
+// Matches reports whether p and other can fill each other: each offers the
+// asset the other asks for, and each receives at least its asking price,
+// i.e. p.MinFromAmount * other.MinFromAmount >= p.MinToAmount * other.MinToAmount.
+func (p *MakeSwapParam) Matches(other *MakeSwapParam) bool {
+	if p.FromAssetID != other.ToAssetID || p.ToAssetID != other.FromAssetID {
+		return false
+	}
+	if !isPositive(p.MinFromAmount) || !isPositive(p.MinToAmount) ||
+		!isPositive(other.MinFromAmount) || !isPositive(other.MinToAmount) {
+		return false
+	}
+	offered := new(big.Int).Mul(p.MinFromAmount, other.MinFromAmount)
+	asked := new(big.Int).Mul(p.MinToAmount, other.MinToAmount)
+	return offered.Cmp(asked) >= 0
+}
+
+// EncodedSize returns the length of the RLP encoding of p, i.e.
+// len(p.ToBytes()), without encoding it.
+func (p *MakeSwapParam) EncodedSize() int {
+	size := 2 * (HashLength + 1) // FromAssetID, ToAssetID
+	size += rlpUint64Size(p.FromStartTime) + rlpUint64Size(p.FromEndTime)
+	size += rlpUint64Size(p.ToStartTime) + rlpUint64Size(p.ToEndTime)
+	size += rlpBigSize(p.MinFromAmount) + rlpBigSize(p.MinToAmount)
+	size += rlpBigSize(p.SwapSize) + rlpBigSize(p.Time)
+	size += int(rlp.ListSize(uint64(len(p.Targes) * (AddressLength + 1))))
+	if len(p.Description) == 1 && p.Description[0] < 0x80 {
+		size++
+	} else {
+		size += int(rlp.ListSize(uint64(len(p.Description))))
+	}
+	return int(rlp.ListSize(uint64(size)))
+}
+
+// rlpUint64Size returns the length of the RLP encoding of i.
+func rlpUint64Size(i uint64) int {
+	if i < 0x80 {
+		return 1
+	}
+	size := 1
+	for ; i > 0; i >>= 8 {
+		size++
+	}
+	return size
+}
+
+// rlpBigSize returns the length of the RLP encoding of the non-negative i.
+// A nil i encodes like zero.
+func rlpBigSize(i *big.Int) int {
+	if i == nil || i.BitLen() <= 7 {
+		return 1
+	}
+	return int(rlp.ListSize(uint64((i.BitLen() + 7) / 8)))
+}
+
 type EmptyParam struct{}
 
 func (p *EmptyParam) ToBytes() ([]byte, error) {
@@ -203,7 +571,7 @@ func DecodeTxInput(input []byte) (interface{}, error) {
 
 	switch fsnCall.Func {
 	case GenNotationFunc:
-		return DecodeFsnCallParam(&fsnCall, &EmptyParam{})
+		return DecodeFsnCallParam(&fsnCall, &GenNotationParam{})
 	case GenAssetFunc:
 		return DecodeFsnCallParam(&fsnCall, &GenAssetParam{})
 	case SendAssetFunc:
@@ -234,59 +602,243 @@ func DecodeTxInput(input []byte) (interface{}, error) {
 }
 
 /////////////////// param checking ///////////////////////
+// Errors returned by the param Check methods. The returned *ParamError
+// carries a descriptive message and matches these with errors.Is.
+var (
+	ErrMissingField       = errors.New("required field not set")
+	ErrZeroValue          = errors.New("value must be greater than 0")
+	ErrValueTooLarge      = errors.New("value too large")
+	ErrZeroAddress        = errors.New("zero address")
+	ErrZeroAssetID        = errors.New("zero asset ID")
+	ErrInvalidDecimals    = errors.New("invalid decimals")
+	ErrInvalidName        = errors.New("invalid name")
+	ErrInvalidSymbol      = errors.New("invalid symbol")
+	ErrDescriptionTooLong = errors.New("description too long")
+	ErrInvalidDescription = errors.New("invalid description")
+	ErrDataTooLong        = errors.New("data too long")
+	ErrTimeRangeInvalid   = errors.New("invalid time range")
+	ErrInvalidType        = errors.New("invalid type")
+	ErrNotSwappable       = errors.New("asset not swappable")
+	ErrTooManyTargets     = errors.New("too many swap targets")
+	ErrInvalidSize        = errors.New("invalid swap size")
+	ErrSwapExpired        = errors.New("swap expired")
+	ErrTakerNotAllowed    = errors.New("swap taker not allowed")
+	ErrNotSwapOwner       = errors.New("not swap owner")
+	ErrSwapNotFound       = errors.New("swap not found")
+	ErrDuplicateTarget    = errors.New("duplicate swap target")
+)
+
+// ParamError is returned by the param Check methods. It names the function
+// and the offending param field and unwraps to one of the errors above.
+type ParamError struct {
+	Func  FSNCallFunc
+	Field string
+	Err   error
+
+	msg string
+}
+
+func newParamError(fn FSNCallFunc, field string, err error, format string, args ...interface{}) error {
+	return &ParamError{Func: fn, Field: field, Err: err, msg: fmt.Sprintf(format, args...)}
+}
+
+// Error returns the descriptive message of the failure.
+func (e *ParamError) Error() string {
+	if e.msg != "" {
+		return e.msg
+	}
+	return fmt.Sprintf("%v %s: %v", e.Func, e.Field, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ParamError) Unwrap() error { return e.Err }
+
+// MaxSwapTargets is the maximum number of target addresses a swap may list.
+const MaxSwapTargets = 100
+
+// fsnCallFuncTakesParam records for every defined FSNCallFunc whether the
+// call carries param Data.
+var fsnCallFuncTakesParam = map[FSNCallFunc]bool{
+	GenNotationFunc:         false,
+	GenAssetFunc:            true,
+	SendAssetFunc:           true,
+	TimeLockFunc:            true,
+	BuyTicketFunc:           true,
+	OldAssetValueChangeFunc: true,
+	MakeSwapFunc:            true,
+	RecallSwapFunc:          true,
+	TakeSwapFunc:            true,
+	EmptyFunc:               false,
+	MakeSwapFuncExt:         true,
+	TakeSwapFuncExt:         true,
+	AssetValueChangeFunc:    true,
+	MakeMultiSwapFunc:       true,
+	RecallMultiSwapFunc:     true,
+	TakeMultiSwapFunc:       true,
+	ReportIllegalFunc:       true,
+}
+
 // Check wacom
 func (p *FSNCallParam) Check(blockNumber *big.Int) error {
+	if !p.Func.IsValid() {
+		return newParamError(p.Func, "Func", ErrInvalidType, "unknown FSNCall function %d", uint8(p.Func))
+	}
+	if p.Func == EmptyFunc {
+		return newParamError(p.Func, "Func", ErrInvalidType, "EmptyFunc is not a callable FSNCall function")
+	}
+	if !fsnCallFuncTakesParam[p.Func] && len(p.Data) != 0 {
+		return newParamError(p.Func, "Data", ErrDataTooLong, "%v takes no param data", p.Func)
+	}
 	return nil
 }
 
 // Check wacom
 func (p *GenAssetParam) Check(blockNumber *big.Int) error {
-	if len(p.Name) == 0 || len(p.Symbol) == 0 || p.Total == nil || p.Total.Cmp(Big0) < 0 {
-		return fmt.Errorf("GenAssetFunc name, symbol and total must be set")
+	var missing string
+	switch {
+	case len(p.Name) == 0:
+		missing = "Name"
+	case len(p.Symbol) == 0:
+		missing = "Symbol"
+	case p.Total == nil || p.Total.Cmp(Big0) < 0:
+		missing = "Total"
+	}
+	if missing != "" {
+		return newParamError(GenAssetFunc, missing, ErrMissingField, "GenAssetFunc name, symbol and total must be set")
 	}
 	if p.Decimals > 18 {
-		return fmt.Errorf("GenAssetFunc decimals must be between 0 and 18")
+		return newParamError(GenAssetFunc, "Decimals", ErrInvalidDecimals, "GenAssetFunc decimals must be between 0 and 18")
 	}
-	if len(p.Description) > 1024 {
-		return fmt.Errorf("GenAsset description length is greater than 1024 chars")
+	if textLength(p.Description, blockNumber) > 1024 {
+		return newParamError(GenAssetFunc, "Description", ErrDescriptionTooLong, "GenAsset description length is greater than 1024 chars")
 	}
-	if len(p.Name) > 128 {
-		return fmt.Errorf("GenAsset name length is greater than 128 chars")
+	if textLength(p.Name, blockNumber) > 128 {
+		return newParamError(GenAssetFunc, "Name", ErrInvalidName, "GenAsset name length is greater than 128 chars")
 	}
-	if len(p.Symbol) > 64 {
-		return fmt.Errorf("GenAsset symbol length is greater than 64 chars")
+	if textLength(p.Symbol, blockNumber) > 64 {
+		return newParamError(GenAssetFunc, "Symbol", ErrInvalidSymbol, "GenAsset symbol length is greater than 64 chars")
 
 	}
+	if IsStrictParamCheckingEnabled(blockNumber) {
+		if p.Total.Sign() == 0 {
+			return newParamError(GenAssetFunc, "Total", ErrZeroValue, "GenAsset total must be greater than 0")
+		}
+		if p.Total.BitLen() > 256 {
+			return newParamError(GenAssetFunc, "Total", ErrValueTooLarge, "GenAsset total exceeds 256 bits")
+		}
+		if !isDisplayable(p.Name) {
+			return newParamError(GenAssetFunc, "Name", ErrInvalidName, "GenAsset name must be printable and not blank")
+		}
+		if !isDisplayable(p.Symbol) {
+			return newParamError(GenAssetFunc, "Symbol", ErrInvalidSymbol, "GenAsset symbol must be printable and not blank")
+		}
+	}
 	return nil
 }
 
+// isPositive reports whether v is set and greater than 0.
+func isPositive(v *big.Int) bool {
+	return v != nil && v.Sign() > 0
+}
+
+// isDisplayable reports whether s contains only printable runes and is not
+// blank after trimming whitespace.
+func isDisplayable(s string) bool {
+	return strings.TrimSpace(s) != "" && isPrintable(s)
+}
+
+// textLength returns the length of s used for param limits. It counts runes
+// once strict param checking is enabled and bytes before.
+func textLength(s string, blockNumber *big.Int) int {
+	if IsStrictParamCheckingEnabled(blockNumber) {
+		return utf8.RuneCountInString(s)
+	}
+	return len(s)
+}
+
+// isPrintable reports whether s contains only printable runes.
+func isPrintable(s string) bool {
+	for _, r := range s {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
 // Check wacom
 func (p *SendAssetParam) Check(blockNumber *big.Int) error {
-	if p.Value == nil || p.Value.Cmp(Big0) <= 0 {
-		return fmt.Errorf("Value must be set and greater than 0")
+	if !isPositive(p.Value) {
+		return newParamError(SendAssetFunc, "Value", ErrZeroValue, "Value must be set and greater than 0")
 	}
-	if p.To == (Address{}) {
-		return fmt.Errorf("receiver address must be set and not zero address")
+	if p.To.IsZero() {
+		return newParamError(SendAssetFunc, "To", ErrZeroAddress, "receiver address must be set and not zero address")
 	}
-	if p.AssetID == (Hash{}) {
-		return fmt.Errorf("empty asset ID, 'asset' must be specified instead of AssetID.")
+	if p.AssetID.IsZero() {
+		return newParamError(SendAssetFunc, "AssetID", ErrZeroAssetID, "empty asset ID, 'asset' must be specified instead of AssetID.")
 	}
 	return nil
 }
 
+// MaxTimeLockDuration is the maximum duration in seconds of a time lock that
+// does not end at TimeLockForever.
+const MaxTimeLockDuration = 10 * 365 * 24 * 3600
+
 // Check wacom
 func (p *TimeLockParam) Check(blockNumber *big.Int, timestamp uint64) error {
+	if IsStrictParamCheckingEnabled(blockNumber) && !p.Type.IsValid() {
+		return newParamError(TimeLockFunc, "Type", ErrInvalidType, "unknown TimeLock type %d", uint(p.Type))
+	}
 
-	if p.Value == nil || p.Value.Cmp(Big0) <= 0 {
-		return fmt.Errorf("Value must be set and greater than 0")
+	if !isPositive(p.Value) {
+		return newParamError(TimeLockFunc, "Value", ErrZeroValue, "Value must be set and greater than 0")
 	}
 	if p.StartTime > p.EndTime {
-		return fmt.Errorf("StartTime must be less than or equal to EndTime")
+		return newParamError(TimeLockFunc, "StartTime", ErrTimeRangeInvalid, "StartTime must be less than or equal to EndTime")
 	}
 	if p.EndTime < timestamp {
-		return fmt.Errorf("EndTime must be greater than latest block time")
+		return newParamError(TimeLockFunc, "EndTime", ErrTimeRangeInvalid, "EndTime must be greater than latest block time")
 	}
+	if IsStrictParamCheckingEnabled(blockNumber) && p.EndTime != TimeLockForever {
+		// a start time in the past is treated as the latest block time
+		start := p.StartTime
+		if start < timestamp {
+			start = timestamp
+		}
+		if p.EndTime-start > MaxTimeLockDuration {
+			return newParamError(TimeLockFunc, "EndTime", ErrTimeRangeInvalid, "TimeLock duration exceeds maximum")
+		}
+	}
+
+	return nil
+}
+
+// Overlaps reports whether p and other lock the same asset for the same
+// receiver during a common second. Time lock windows include both their
+// StartTime and EndTime.
+func (p *TimeLockParam) Overlaps(other *TimeLockParam) bool {
+	if other == nil || p.AssetID != other.AssetID || p.To != other.To {
+		return false
+	}
+	return p.StartTime <= other.EndTime && other.StartTime <= p.EndTime
+}
 
+// Ticket lifetime bounds in seconds.
+const (
+	MinTicketLifetime = 30 * 24 * 3600
+	MaxTicketLifetime = 365 * 24 * 3600
+)
+
+// Duration returns the lifetime of the ticket in seconds.
+func (p *BuyTicketParam) Duration() uint64 {
+	if p.End <= p.Start {
+		return 0
+	}
+	return p.End - p.Start
+}
+
+// Check wacom
+func (p *GenNotationParam) Check(blockNumber *big.Int) error {
 	return nil
 }
 
@@ -294,23 +846,26 @@ func (p *TimeLockParam) Check(blockNumber *big.Int, timestamp uint64) error {
 func (p *BuyTicketParam) Check(blockNumber *big.Int, timestamp uint64) error {
 	start, end := p.Start, p.End
 	// check lifetime too short ticket
-	if end <= start || end < start+30*24*3600 {
-		return fmt.Errorf("BuyTicket end must be greater than start + 1 month")
+	if end <= start || end < start+MinTicketLifetime {
+		return newParamError(BuyTicketFunc, "End", ErrTimeRangeInvalid, "BuyTicket end must be greater than start + 1 month")
+	}
+	if IsStrictParamCheckingEnabled(blockNumber) && p.Duration() > MaxTicketLifetime {
+		return newParamError(BuyTicketFunc, "End", ErrTimeRangeInvalid, "BuyTicket lifetime too long")
 	}
 	if timestamp != 0 {
 		// check future ticket
 		if start > timestamp+3*3600 {
-			return fmt.Errorf("BuyTicket start must be lower than latest block time + 3 hour")
+			return newParamError(BuyTicketFunc, "Start", ErrTimeRangeInvalid, "BuyTicket start must be lower than latest block time + 3 hour")
 		}
 		// check ticket lifetime
 		if IsHardFork(2, blockNumber) {
 			// use 29 days here to check lifetime, to relax auto buy ticket tx checking in txpool
 			if end < timestamp+29*24*3600 {
-				return fmt.Errorf("BuyTicket end must be greater than latest block time + 1 month")
+				return newParamError(BuyTicketFunc, "End", ErrTimeRangeInvalid, "BuyTicket end must be greater than latest block time + 1 month")
 			}
 		} else {
 			if end < timestamp+7*24*3600 {
-				return fmt.Errorf("BuyTicket end must be greater than latest block time + 1 week")
+				return newParamError(BuyTicketFunc, "End", ErrTimeRangeInvalid, "BuyTicket end must be greater than latest block time + 1 week")
 			}
 		}
 	}
@@ -319,58 +874,96 @@ func (p *BuyTicketParam) Check(blockNumber *big.Int, timestamp uint64) error {
 
 // Check wacom
 func (p *AssetValueChangeExParam) Check(blockNumber *big.Int) error {
-	if p.Value == nil || p.Value.Cmp(Big0) <= 0 {
-		return fmt.Errorf("Value must be set and greater than 0")
+	if !isPositive(p.Value) {
+		return newParamError(AssetValueChangeFunc, "Value", ErrZeroValue, "Value must be set and greater than 0")
 	}
+	// TransacData is an opaque byte blob, its limit is in bytes
 	if len(p.TransacData) > 256 {
-		return fmt.Errorf("TransacData must not be greater than 256")
+		return newParamError(AssetValueChangeFunc, "TransacData", ErrDataTooLong, "TransacData must not be greater than 256")
+	}
+	if IsStrictParamCheckingEnabled(blockNumber) && p.To.IsZero() {
+		return newParamError(AssetValueChangeFunc, "To", ErrZeroAddress, "receiver address must be set")
 	}
 	return nil
 }
 
 // Check wacom
 func (p *MakeSwapParam) Check(blockNumber *big.Int, timestamp uint64) error {
-	if p.MinFromAmount == nil || p.MinFromAmount.Cmp(Big0) <= 0 ||
-		p.MinToAmount == nil || p.MinToAmount.Cmp(Big0) <= 0 ||
-		p.SwapSize == nil || p.SwapSize.Cmp(Big0) <= 0 {
-		return fmt.Errorf("MinFromAmount,MinToAmount and SwapSize must be ge 1")
+	var invalid string
+	switch {
+	case !isPositive(p.MinFromAmount):
+		invalid = "MinFromAmount"
+	case !isPositive(p.MinToAmount):
+		invalid = "MinToAmount"
+	case !isPositive(p.SwapSize):
+		invalid = "SwapSize"
 	}
-	if len(p.Description) > 1024 {
-		return fmt.Errorf("MakeSwap description length is greater than 1024 chars")
+	if invalid != "" {
+		return newParamError(MakeSwapFunc, invalid, ErrZeroValue, "MinFromAmount,MinToAmount and SwapSize must be ge 1")
 	}
-	total := new(big.Int).Mul(p.MinFromAmount, p.SwapSize)
-	if total.Cmp(Big0) <= 0 {
-		return fmt.Errorf("size * MinFromAmount too large")
+	if textLength(p.Description, blockNumber) > 1024 {
+		return newParamError(MakeSwapFunc, "Description", ErrDescriptionTooLong, "MakeSwap description length is greater than 1024 chars")
 	}
-
-	toTotal := new(big.Int).Mul(p.MinToAmount, p.SwapSize)
-	if toTotal.Cmp(Big0) <= 0 {
-		return fmt.Errorf("size * MinToAmount too large")
+	if IsStrictParamCheckingEnabled(blockNumber) {
+		// the totals must be representable as account balances
+		if _, ok := mulWithin256(p.MinFromAmount, p.SwapSize); !ok {
+			return newParamError(MakeSwapFunc, "MinFromAmount", ErrValueTooLarge, "size * MinFromAmount exceeds 256 bits")
+		}
+		if _, ok := mulWithin256(p.MinToAmount, p.SwapSize); !ok {
+			return newParamError(MakeSwapFunc, "MinToAmount", ErrValueTooLarge, "size * MinToAmount exceeds 256 bits")
+		}
 	}
 
 	if p.FromStartTime > p.FromEndTime {
-		return fmt.Errorf("MakeSwap FromStartTime > FromEndTime")
+		return newParamError(MakeSwapFunc, "FromStartTime", ErrTimeRangeInvalid, "MakeSwap FromStartTime > FromEndTime")
 	}
 	if p.ToStartTime > p.ToEndTime {
-		return fmt.Errorf("MakeSwap ToStartTime > ToEndTime")
+		return newParamError(MakeSwapFunc, "ToStartTime", ErrTimeRangeInvalid, "MakeSwap ToStartTime > ToEndTime")
 	}
 
 	if p.FromEndTime <= timestamp {
-		return fmt.Errorf("MakeSwap FromEndTime <= latest blockTime")
+		return newParamError(MakeSwapFunc, "FromEndTime", ErrTimeRangeInvalid, "MakeSwap FromEndTime <= latest blockTime")
 	}
 	if p.ToEndTime <= timestamp {
-		return fmt.Errorf("MakeSwap ToEndTime <= latest blockTime")
+		return newParamError(MakeSwapFunc, "ToEndTime", ErrTimeRangeInvalid, "MakeSwap ToEndTime <= latest blockTime")
 	}
 
 	if p.ToAssetID == OwnerUSANAssetID {
-		return fmt.Errorf("USAN's cannot be swapped")
+		return newParamError(MakeSwapFunc, "ToAssetID", ErrNotSwappable, "USAN's cannot be swapped")
+	}
+
+	if IsStrictParamCheckingEnabled(blockNumber) {
+		if !isPrintable(p.Description) {
+			return newParamError(MakeSwapFunc, "Description", ErrInvalidDescription, "MakeSwap description must be printable")
+		}
+		if len(p.Targes) > MaxSwapTargets {
+			return newParamError(MakeSwapFunc, "Targes", ErrTooManyTargets, "MakeSwap targets list too large")
+		}
+		seen := make(map[Address]struct{}, len(p.Targes))
+		for _, target := range p.Targes {
+			if target.IsZero() {
+				return newParamError(MakeSwapFunc, "Targes", ErrZeroAddress, "MakeSwap targets must not contain the zero address")
+			}
+			if _, ok := seen[target]; ok {
+				return newParamError(MakeSwapFunc, "Targes", ErrDuplicateTarget, "MakeSwap target %v listed more than once", target.Hex())
+			}
+			seen[target] = struct{}{}
+		}
+		// swapping an asset for itself only makes sense between disjoint time ranges
+		if p.FromAssetID == p.ToAssetID &&
+			p.FromStartTime <= p.ToEndTime && p.ToStartTime <= p.FromEndTime {
+			return newParamError(MakeSwapFunc, "ToAssetID", ErrTimeRangeInvalid, "MakeSwap of the same asset must have disjoint from and to time ranges")
+		}
 	}
 
 	return nil
 }
 
 // Check wacom
-func (p *RecallSwapParam) Check(blockNumber *big.Int, swap *Swap) error {
+func (p *RecallSwapParam) Check(blockNumber *big.Int, swap *Swap, caller Address) error {
+	if swap.Owner != caller {
+		return newParamError(RecallSwapFunc, "SwapID", ErrNotSwapOwner, "only swap owner can recall")
+	}
 	return nil
 }
 
@@ -379,88 +972,128 @@ func (p *TakeSwapParam) Check(blockNumber *big.Int, swap *Swap, timestamp uint64
 	if p.Size == nil || p.Size.Cmp(Big0) <= 0 ||
 		swap.SwapSize == nil || p.Size.Cmp(swap.SwapSize) > 0 {
 
-		return fmt.Errorf("Size must be ge 1 and le Swapsize")
+		return newParamError(TakeSwapFunc, "Size", ErrInvalidSize, "Size must be ge 1 and le Swapsize")
+	}
+	if IsStrictParamCheckingEnabled(blockNumber) {
+		if swap.MinFromAmount == nil || swap.MinToAmount == nil {
+			return newParamError(TakeSwapFunc, "SwapID", ErrMissingField, "swap amounts must be set")
+		}
+		if _, ok := mulWithin256(swap.MinFromAmount, p.Size); !ok {
+			return newParamError(TakeSwapFunc, "Size", ErrValueTooLarge, "size * MinFromAmount exceeds 256 bits")
+		}
+		if _, ok := mulWithin256(swap.MinToAmount, p.Size); !ok {
+			return newParamError(TakeSwapFunc, "Size", ErrValueTooLarge, "size * MinToAmount exceeds 256 bits")
+		}
 	}
 
 	if swap.FromEndTime <= timestamp {
-		return fmt.Errorf("swap expired: FromEndTime <= latest blockTime")
+		return newParamError(TakeSwapFunc, "SwapID", ErrSwapExpired, "swap expired: FromEndTime <= latest blockTime")
 	}
 	if swap.ToEndTime <= timestamp {
-		return fmt.Errorf("swap expired: ToEndTime <= latest blockTime")
+		return newParamError(TakeSwapFunc, "SwapID", ErrSwapExpired, "swap expired: ToEndTime <= latest blockTime")
 	}
 
 	return nil
 }
 
+// CheckWithTaker runs Check and additionally verifies that taker is allowed
+// to take the swap.
+func (p *TakeSwapParam) CheckWithTaker(blockNumber *big.Int, swap *Swap, timestamp uint64, taker Address) error {
+	if err := p.Check(blockNumber, swap, timestamp); err != nil {
+		return err
+	}
+	if IsPrivateSwapCheckingEnabled(blockNumber) && !swap.AllowsTaker(taker) {
+		return newParamError(TakeSwapFunc, "SwapID", ErrTakerNotAllowed, "swap taker does not match the specified targets")
+	}
+	return nil
+}
+
+// SwapStore looks up swaps by ID.
+type SwapStore interface {
+	GetSwap(id Hash) (*Swap, error)
+}
+
+// CheckWithStore fetches the swap p.SwapID from store and runs Check on it.
+func (p *TakeSwapParam) CheckWithStore(blockNumber *big.Int, store SwapStore, timestamp uint64) error {
+	swap, err := store.GetSwap(p.SwapID)
+	if err != nil {
+		return newParamError(TakeSwapFunc, "SwapID", ErrSwapNotFound, "swap not found: %v", err)
+	}
+	if swap == nil {
+		return newParamError(TakeSwapFunc, "SwapID", ErrSwapNotFound, "swap not found")
+	}
+	return p.Check(blockNumber, swap, timestamp)
+}
+
 // Check wacom
 func (p *MakeMultiSwapParam) Check(blockNumber *big.Int, timestamp uint64) error {
 	if p.MinFromAmount == nil || len(p.MinFromAmount) == 0 {
-		return fmt.Errorf("MinFromAmount must be specified")
+		return newParamError(MakeMultiSwapFunc, "MinFromAmount", ErrMissingField, "MinFromAmount must be specified")
 	}
 	if p.MinToAmount == nil || len(p.MinToAmount) == 0 {
-		return fmt.Errorf("MinToAmount must be specified")
+		return newParamError(MakeMultiSwapFunc, "MinToAmount", ErrMissingField, "MinToAmount must be specified")
 	}
 	if p.SwapSize == nil || p.SwapSize.Cmp(Big0) <= 0 {
-		return fmt.Errorf("SwapSize must be ge 1")
+		return newParamError(MakeMultiSwapFunc, "SwapSize", ErrZeroValue, "SwapSize must be ge 1")
 	}
 
 	if len(p.MinFromAmount) != len(p.FromEndTime) ||
 		len(p.MinFromAmount) != len(p.FromAssetID) ||
 		len(p.MinFromAmount) != len(p.FromStartTime) {
-		return fmt.Errorf("MinFromAmount FromEndTime and FromStartTime array length must be same size")
+		return newParamError(MakeMultiSwapFunc, "FromEndTime", ErrMissingField, "MinFromAmount FromEndTime and FromStartTime array length must be same size")
 	}
 	if len(p.MinToAmount) != len(p.ToEndTime) ||
 		len(p.MinToAmount) != len(p.ToAssetID) ||
 		len(p.MinToAmount) != len(p.ToStartTime) {
-		return fmt.Errorf("MinToAmount ToEndTime and ToStartTime array length must be same size")
+		return newParamError(MakeMultiSwapFunc, "ToEndTime", ErrMissingField, "MinToAmount ToEndTime and ToStartTime array length must be same size")
 	}
 
 	ln := len(p.MinFromAmount)
 	for i := 0; i < ln; i++ {
 		if p.MinFromAmount[i] == nil || p.MinFromAmount[i].Cmp(Big0) <= 0 {
-			return fmt.Errorf("MinFromAmounts must be ge 1")
+			return newParamError(MakeMultiSwapFunc, "MinFromAmount", ErrZeroValue, "MinFromAmounts must be ge 1")
 		}
 		total := new(big.Int).Mul(p.MinFromAmount[i], p.SwapSize)
 		if total.Cmp(Big0) <= 0 {
-			return fmt.Errorf("size * MinFromAmount too large")
+			return newParamError(MakeMultiSwapFunc, "MinFromAmount", ErrValueTooLarge, "size * MinFromAmount too large")
 		}
 		if p.FromStartTime[i] > p.FromEndTime[i] {
-			return fmt.Errorf("MakeMultiSwap FromStartTime > FromEndTime")
+			return newParamError(MakeMultiSwapFunc, "FromStartTime", ErrTimeRangeInvalid, "MakeMultiSwap FromStartTime > FromEndTime")
 		}
 		if p.FromEndTime[i] <= timestamp {
-			return fmt.Errorf("MakeMultiSwap FromEndTime <= latest blockTime")
+			return newParamError(MakeMultiSwapFunc, "FromEndTime", ErrTimeRangeInvalid, "MakeMultiSwap FromEndTime <= latest blockTime")
 		}
 	}
 
 	ln = len(p.MinToAmount)
 	for i := 0; i < ln; i++ {
 		if p.MinToAmount[i] == nil || p.MinToAmount[i].Cmp(Big0) <= 0 {
-			return fmt.Errorf("MinToAmounts must be ge 1")
+			return newParamError(MakeMultiSwapFunc, "MinToAmount", ErrZeroValue, "MinToAmounts must be ge 1")
 		}
 		toTotal := new(big.Int).Mul(p.MinToAmount[i], p.SwapSize)
 		if toTotal.Cmp(Big0) <= 0 {
-			return fmt.Errorf("size * MinToAmount too large")
+			return newParamError(MakeMultiSwapFunc, "MinToAmount", ErrValueTooLarge, "size * MinToAmount too large")
 		}
 		if p.ToStartTime[i] > p.ToEndTime[i] {
-			return fmt.Errorf("MakeMultiSwap ToStartTime > ToEndTime")
+			return newParamError(MakeMultiSwapFunc, "ToStartTime", ErrTimeRangeInvalid, "MakeMultiSwap ToStartTime > ToEndTime")
 		}
 		if p.ToEndTime[i] <= timestamp {
-			return fmt.Errorf("MakeMultiSwap ToEndTime <= latest blockTime")
+			return newParamError(MakeMultiSwapFunc, "ToEndTime", ErrTimeRangeInvalid, "MakeMultiSwap ToEndTime <= latest blockTime")
 		}
 	}
 
 	if len(p.Description) > 1024 {
-		return fmt.Errorf("MakeSwap description length is greater than 1024 chars")
+		return newParamError(MakeMultiSwapFunc, "Description", ErrDescriptionTooLong, "MakeSwap description length is greater than 1024 chars")
 	}
 
 	for _, toAssetID := range p.ToAssetID {
 		if toAssetID == OwnerUSANAssetID {
-			return fmt.Errorf("USAN's cannot be multi swapped")
+			return newParamError(MakeMultiSwapFunc, "ToAssetID", ErrNotSwappable, "USAN's cannot be multi swapped")
 		}
 	}
 	for _, fromAssetID := range p.FromAssetID {
 		if fromAssetID == OwnerUSANAssetID {
-			return fmt.Errorf("USAN's cannot be multi swapped")
+			return newParamError(MakeMultiSwapFunc, "FromAssetID", ErrNotSwappable, "USAN's cannot be multi swapped")
 		}
 	}
 	return nil
@@ -476,21 +1109,114 @@ func (p *TakeMultiSwapParam) Check(blockNumber *big.Int, swap *MultiSwap, timest
 	if p.Size == nil || p.Size.Cmp(Big0) <= 0 ||
 		swap.SwapSize == nil || p.Size.Cmp(swap.SwapSize) > 0 {
 
-		return fmt.Errorf("Size must be ge 1 and le Swapsize")
+		return newParamError(TakeMultiSwapFunc, "Size", ErrInvalidSize, "Size must be ge 1 and le Swapsize")
 	}
 
 	ln := len(swap.FromEndTime)
 	for i := 0; i < ln; i++ {
 		if swap.FromEndTime[i] <= timestamp {
-			return fmt.Errorf("swap expired: FromEndTime <= latest blockTime")
+			return newParamError(TakeMultiSwapFunc, "SwapID", ErrSwapExpired, "swap expired: FromEndTime <= latest blockTime")
 		}
 	}
 
 	ln = len(swap.ToEndTime)
 	for i := 0; i < ln; i++ {
 		if swap.ToEndTime[i] <= timestamp {
-			return fmt.Errorf("swap expired: ToEndTime <= latest blockTime")
+			return newParamError(TakeMultiSwapFunc, "SwapID", ErrSwapExpired, "swap expired: ToEndTime <= latest blockTime")
 		}
 	}
 	return nil
 }
+
+// ValidationContext carries the chain state needed to validate an FSNCall.
+// The swap lookups are only required for the swap functions using them.
+type ValidationContext struct {
+	BlockNumber  *big.Int
+	Timestamp    uint64
+	Caller       Address
+	GetSwap      func(id Hash) (*Swap, error)
+	GetMultiSwap func(id Hash) (*MultiSwap, error)
+}
+
+func (ctx *ValidationContext) swap(fn FSNCallFunc, id Hash) (*Swap, error) {
+	if ctx.GetSwap == nil {
+		return nil, fmt.Errorf("%v validation needs a swap lookup", fn)
+	}
+	swap, err := ctx.GetSwap(id)
+	if err != nil {
+		return nil, newParamError(fn, "SwapID", ErrSwapNotFound, "swap not found: %v", err)
+	}
+	if swap == nil {
+		return nil, newParamError(fn, "SwapID", ErrSwapNotFound, "swap not found")
+	}
+	return swap, nil
+}
+
+func (ctx *ValidationContext) multiSwap(fn FSNCallFunc, id Hash) (*MultiSwap, error) {
+	if ctx.GetMultiSwap == nil {
+		return nil, fmt.Errorf("%v validation needs a multi swap lookup", fn)
+	}
+	swap, err := ctx.GetMultiSwap(id)
+	if err != nil {
+		return nil, newParamError(fn, "SwapID", ErrSwapNotFound, "swap not found: %v", err)
+	}
+	if swap == nil {
+		return nil, newParamError(fn, "SwapID", ErrSwapNotFound, "swap not found")
+	}
+	return swap, nil
+}
+
+// ValidateFSNCall checks p, decodes its param and runs the param's Check
+// with the arguments it needs taken from ctx.
+func ValidateFSNCall(p *FSNCallParam, ctx ValidationContext) error {
+	if err := p.Check(ctx.BlockNumber); err != nil {
+		return err
+	}
+	param, err := p.DecodeTyped()
+	if err != nil {
+		return err
+	}
+	switch param := param.(type) {
+	case *GenNotationParam:
+		return param.Check(ctx.BlockNumber)
+	case *GenAssetParam:
+		return param.Check(ctx.BlockNumber)
+	case *SendAssetParam:
+		return param.Check(ctx.BlockNumber)
+	case *TimeLockParam:
+		return param.Check(ctx.BlockNumber, ctx.Timestamp)
+	case *BuyTicketParam:
+		return param.Check(ctx.BlockNumber, ctx.Timestamp)
+	case *AssetValueChangeExParam:
+		return param.Check(ctx.BlockNumber)
+	case *MakeSwapParam:
+		return param.Check(ctx.BlockNumber, ctx.Timestamp)
+	case *RecallSwapParam:
+		swap, err := ctx.swap(p.Func, param.SwapID)
+		if err != nil {
+			return err
+		}
+		return param.Check(ctx.BlockNumber, swap, ctx.Caller)
+	case *TakeSwapParam:
+		swap, err := ctx.swap(p.Func, param.SwapID)
+		if err != nil {
+			return err
+		}
+		return param.CheckWithTaker(ctx.BlockNumber, swap, ctx.Timestamp, ctx.Caller)
+	case *MakeMultiSwapParam:
+		return param.Check(ctx.BlockNumber, ctx.Timestamp)
+	case *RecallMultiSwapParam:
+		swap, err := ctx.multiSwap(p.Func, param.SwapID)
+		if err != nil {
+			return err
+		}
+		return param.Check(ctx.BlockNumber, swap)
+	case *TakeMultiSwapParam:
+		swap, err := ctx.multiSwap(p.Func, param.SwapID)
+		if err != nil {
+			return err
+		}
+		return param.Check(ctx.BlockNumber, swap, ctx.Timestamp)
+	}
+	return fmt.Errorf("can not validate param of FuncType %v (%v)", uint8(p.Func), p.Func.Name())
+}
@@ -0,0 +1,1321 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/FusionFoundation/efsn/rlp"
+)
+
+func TestFSNCallParamRoundTrip(t *testing.T) {
+	genAsset := GenAssetParam{
+		Name:        "Test Asset",
+		Symbol:      "TST",
+		Decimals:    18,
+		Total:       big.NewInt(1000000),
+		CanChange:   true,
+		Description: "test",
+	}
+	data, err := genAsset.ToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := FSNCallParam{Func: GenAssetFunc, Data: data}
+	enc, err := call.ToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dec, err := DecodeFSNCallParam(enc)
+	if err != nil {
+		t.Fatalf("DecodeFSNCallParam error: %v", err)
+	}
+	if !reflect.DeepEqual(dec, &call) {
+		t.Fatalf("FSNCallParam mismatch: have %+v, want %+v", dec, &call)
+	}
+	var decAsset GenAssetParam
+	if err := dec.DecodeData(&decAsset); err != nil {
+		t.Fatalf("DecodeData error: %v", err)
+	}
+	if !reflect.DeepEqual(decAsset, genAsset) {
+		t.Fatalf("GenAssetParam mismatch: have %+v, want %+v", decAsset, genAsset)
+	}
+
+	if _, err := DecodeFSNCallParam([]byte{0x01, 0x02}); err == nil {
+		t.Error("expected error decoding malformed FSNCallParam")
+	}
+}
+
+func TestFSNCallParamDecodeTyped(t *testing.T) {
+	tests := []struct {
+		fn    FSNCallFunc
+		param interface {
+			ToBytes() ([]byte, error)
+		}
+	}{
+		{GenNotationFunc, &GenNotationParam{}},
+		{GenAssetFunc, &GenAssetParam{Name: "Test", Symbol: "TST", Decimals: 2, Total: big.NewInt(100)}},
+		{SendAssetFunc, &SendAssetParam{AssetID: SystemAssetID, To: HexToAddress("0x01"), Value: big.NewInt(5)}},
+		{BuyTicketFunc, &BuyTicketParam{Start: 100, End: 200}},
+		{MakeSwapFunc, &MakeSwapParam{
+			FromAssetID:   SystemAssetID,
+			FromEndTime:   TimeLockForever,
+			MinFromAmount: big.NewInt(1),
+			ToAssetID:     HexToHash("0x02"),
+			ToEndTime:     TimeLockForever,
+			MinToAmount:   big.NewInt(2),
+			SwapSize:      big.NewInt(3),
+			Targes:        []Address{HexToAddress("0x03")},
+			Time:          big.NewInt(4),
+			Description:   "swap",
+		}},
+	}
+	for _, test := range tests {
+		data, err := test.param.ToBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		call := FSNCallParam{Func: test.fn, Data: data}
+		dec, err := call.DecodeTyped()
+		if err != nil {
+			t.Errorf("%v: DecodeTyped error: %v", test.fn.Name(), err)
+			continue
+		}
+		if !reflect.DeepEqual(dec, test.param) {
+			t.Errorf("%v: param mismatch: have %+v, want %+v", test.fn.Name(), dec, test.param)
+		}
+	}
+
+	for _, fn := range []FSNCallFunc{EmptyFunc, OldAssetValueChangeFunc, ReportIllegalFunc, UnknownFunc, 42} {
+		call := FSNCallParam{Func: fn}
+		if _, err := call.DecodeTyped(); err == nil {
+			t.Errorf("FuncType %d: expected error", fn)
+		}
+	}
+	call := FSNCallParam{Func: SendAssetFunc, Data: []byte{0x01}}
+	if _, err := call.DecodeTyped(); err == nil {
+		t.Error("expected error decoding malformed SendAssetParam")
+	}
+}
+
+func TestParamEncodeTo(t *testing.T) {
+	params := []interface {
+		ToBytes() ([]byte, error)
+		EncodeTo(*bytes.Buffer) error
+	}{
+		&FSNCallParam{Func: SendAssetFunc, Data: []byte{0x01, 0x02}},
+		&GenNotationParam{},
+		newTestGenAssetParam(),
+		&SendAssetParam{AssetID: SystemAssetID, To: HexToAddress("0x01"), Value: big.NewInt(5)},
+		&TimeLockParam{Type: AssetToTimeLock, AssetID: SystemAssetID, To: HexToAddress("0x01"), StartTime: 1, EndTime: 2, Value: big.NewInt(5)},
+		&BuyTicketParam{Start: 100, End: 200},
+		&AssetValueChangeExParam{AssetID: SystemAssetID, To: HexToAddress("0x01"), Value: big.NewInt(5), TransacData: "data"},
+		newTestMakeSwapParam(),
+		&RecallSwapParam{SwapID: HexToHash("0x01")},
+		&TakeSwapParam{SwapID: HexToHash("0x01"), Size: big.NewInt(1)},
+		&MakeMultiSwapParam{FromAssetID: []Hash{SystemAssetID}, MinFromAmount: []*big.Int{big.NewInt(1)}},
+		&RecallMultiSwapParam{SwapID: HexToHash("0x01")},
+		&TakeMultiSwapParam{SwapID: HexToHash("0x01"), Size: big.NewInt(1)},
+	}
+	var buf bytes.Buffer
+	for _, p := range params {
+		want, err := p.ToBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Reset()
+		buf.WriteString("prefix")
+		if err := p.EncodeTo(&buf); err != nil {
+			t.Fatalf("%T: EncodeTo error: %v", p, err)
+		}
+		if have := buf.Bytes()[len("prefix"):]; !bytes.Equal(have, want) {
+			t.Errorf("%T: EncodeTo = %x, ToBytes = %x", p, have, want)
+		}
+	}
+}
+
+func BenchmarkParamEncode(b *testing.B) {
+	p := newTestMakeSwapParam()
+	p.Description = "swap"
+	b.Run("ToBytes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			p.ToBytes()
+		}
+	})
+	b.Run("EncodeTo", func(b *testing.B) {
+		var buf bytes.Buffer
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf.Reset()
+			p.EncodeTo(&buf)
+		}
+	})
+}
+
+func TestGenNotationParam(t *testing.T) {
+	p := &GenNotationParam{}
+	data, err := p.ToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 0 {
+		t.Errorf("GenNotationParam encoding should be empty, got %x", data)
+	}
+	if err := p.Check(nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestFSNCallParamCheckFunc(t *testing.T) {
+	tests := []struct {
+		fn    FSNCallFunc
+		valid bool
+	}{
+		{GenNotationFunc, true},
+		{SendAssetFunc, true},
+		{ReportIllegalFunc, true},
+		{ReportIllegalFunc + 1, false},
+		{42, false},
+		{UnknownFunc, false},
+	}
+	for _, test := range tests {
+		if valid := test.fn.IsValid(); valid != test.valid {
+			t.Errorf("FSNCallFunc(%d).IsValid() = %v, want %v", uint8(test.fn), valid, test.valid)
+		}
+		p := FSNCallParam{Func: test.fn}
+		err := p.Check(Big0)
+		if test.valid && err != nil {
+			t.Errorf("FSNCallFunc(%d): unexpected error: %v", uint8(test.fn), err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("FSNCallFunc(%d): expected error", uint8(test.fn))
+		}
+	}
+	p := FSNCallParam{Func: 42}
+	if err := p.Check(Big0); err == nil || err.Error() != "unknown FSNCall function 42" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	data := []byte{0xc0}
+	if err := (&FSNCallParam{Func: EmptyFunc}).Check(Big0); err == nil {
+		t.Error("expected error for EmptyFunc")
+	}
+	if err := (&FSNCallParam{Func: EmptyFunc, Data: data}).Check(Big0); err == nil {
+		t.Error("expected error for EmptyFunc with data")
+	}
+	if err := (&FSNCallParam{Func: GenNotationFunc, Data: data}).Check(Big0); err == nil {
+		t.Error("expected error for GenNotationFunc with data")
+	}
+	if err := (&FSNCallParam{Func: SendAssetFunc, Data: data}).Check(Big0); err != nil {
+		t.Errorf("SendAssetFunc with data: unexpected error: %v", err)
+	}
+	for fn := range fsnCallFuncNames {
+		if _, ok := fsnCallFuncTakesParam[fn]; !ok {
+			t.Errorf("%v missing from fsnCallFuncTakesParam", fn)
+		}
+	}
+}
+
+func newTestMakeSwapParam() *MakeSwapParam {
+	return &MakeSwapParam{
+		FromAssetID:   SystemAssetID,
+		FromStartTime: TimeLockNow,
+		FromEndTime:   TimeLockForever,
+		MinFromAmount: big.NewInt(1),
+		ToAssetID:     HexToHash("0x01"),
+		ToStartTime:   TimeLockNow,
+		ToEndTime:     TimeLockForever,
+		MinToAmount:   big.NewInt(1),
+		SwapSize:      big.NewInt(1),
+	}
+}
+
+func TestMakeSwapParamCheckSameAsset(t *testing.T) {
+	tests := []struct {
+		sameAsset          bool
+		fromStart, fromEnd uint64
+		toStart, toEnd     uint64
+		ok                 bool
+	}{
+		{true, 1000, 1999, 2000, 3000, true},
+		{true, 2000, 3000, 1000, 1999, true},
+		{true, 1000, 2000, 2000, 3000, false},
+		{true, 1000, 3000, 1500, 2000, false},
+		{true, TimeLockNow, TimeLockForever, TimeLockNow, TimeLockForever, false},
+		{false, 1000, 2000, 2000, 3000, true},
+		{false, TimeLockNow, TimeLockForever, TimeLockNow, TimeLockForever, true},
+	}
+	for i, test := range tests {
+		p := newTestMakeSwapParam()
+		if test.sameAsset {
+			p.ToAssetID = p.FromAssetID
+		}
+		p.FromStartTime, p.FromEndTime = test.fromStart, test.fromEnd
+		p.ToStartTime, p.ToEndTime = test.toStart, test.toEnd
+		err := p.Check(nil, 100)
+		if test.ok && err != nil {
+			t.Errorf("test #%d: unexpected error: %v", i, err)
+		}
+		if !test.ok && err == nil {
+			t.Errorf("test #%d: expected error", i)
+		}
+	}
+
+	// blocks before the fork keep the old rules
+	p := newTestMakeSwapParam()
+	p.ToAssetID = p.FromAssetID
+	if err := p.Check(Big0, 100); err != nil {
+		t.Errorf("pre-fork check failed: %v", err)
+	}
+}
+
+func TestMakeSwapParamCheckTargets(t *testing.T) {
+	for _, n := range []int{0, 1, MaxSwapTargets, MaxSwapTargets + 1} {
+		p := newTestMakeSwapParam()
+		p.Targes = make([]Address, n)
+		for i := range p.Targes {
+			p.Targes[i] = BigToAddress(big.NewInt(int64(i + 1)))
+		}
+		err := p.Check(nil, 100)
+		if n <= MaxSwapTargets && err != nil {
+			t.Errorf("%d targets: unexpected error: %v", n, err)
+		}
+		if n > MaxSwapTargets && (err == nil || err.Error() != "MakeSwap targets list too large") {
+			t.Errorf("%d targets: unexpected error: %v", n, err)
+		}
+	}
+}
+
+func TestTakeSwapParamCheckWithTaker(t *testing.T) {
+	taker := HexToAddress("0x01")
+	swap := &Swap{
+		FromEndTime:   TimeLockForever,
+		ToEndTime:     TimeLockForever,
+		MinFromAmount: big.NewInt(1),
+		MinToAmount:   big.NewInt(1),
+		SwapSize:      big.NewInt(10),
+	}
+	p := &TakeSwapParam{Size: big.NewInt(1)}
+	if err := p.CheckWithTaker(nil, swap, 100, taker); err != nil {
+		t.Errorf("open swap: unexpected error: %v", err)
+	}
+	swap.Targes = []Address{taker}
+	if err := p.CheckWithTaker(nil, swap, 100, taker); err != nil {
+		t.Errorf("matching taker: unexpected error: %v", err)
+	}
+	if err := p.CheckWithTaker(nil, swap, 100, HexToAddress("0x02")); err == nil {
+		t.Error("non-matching taker: expected error")
+	}
+	p.Size = big.NewInt(11)
+	if err := p.CheckWithTaker(nil, swap, 100, taker); err == nil {
+		t.Error("oversized take: expected error")
+	}
+}
+
+func TestTimeLockParamCheckType(t *testing.T) {
+	tests := []struct {
+		typ   TimeLockType
+		name  string
+		valid bool
+	}{
+		{AssetToTimeLock, "AssetToTimeLock", true},
+		{TimeLockToTimeLock, "TimeLockToTimeLock", true},
+		{TimeLockToAsset, "TimeLockToAsset", true},
+		{SmartTransfer, "SmartTransfer", true},
+		{SmartTransfer + 1, "TimeLockType(4)", false},
+	}
+	for _, test := range tests {
+		if name := test.typ.String(); name != test.name {
+			t.Errorf("TimeLockType(%d).String() = %q, want %q", uint(test.typ), name, test.name)
+		}
+		if valid := test.typ.IsValid(); valid != test.valid {
+			t.Errorf("TimeLockType(%d).IsValid() = %v, want %v", uint(test.typ), valid, test.valid)
+		}
+		p := &TimeLockParam{
+			Type:      test.typ,
+			StartTime: 100,
+			EndTime:   200,
+			Value:     big.NewInt(1),
+		}
+		err := p.Check(nil, 100)
+		if test.valid && err != nil {
+			t.Errorf("%v: unexpected error: %v", test.typ, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%v: expected error", test.typ)
+		}
+	}
+}
+
+func newTestGenAssetParam() *GenAssetParam {
+	return &GenAssetParam{
+		Name:     "Test Asset",
+		Symbol:   "TST",
+		Decimals: 18,
+		Total:    big.NewInt(1000000),
+	}
+}
+
+func TestGenAssetParamCheckTotal(t *testing.T) {
+	tests := []struct {
+		total *big.Int
+		err   string
+	}{
+		{big.NewInt(1), ""},
+		{new(big.Int).Sub(new(big.Int).Lsh(Big1, 256), Big1), ""},
+		{new(big.Int).Lsh(Big1, 256), "GenAsset total exceeds 256 bits"},
+		{big.NewInt(0), "GenAsset total must be greater than 0"},
+	}
+	for _, test := range tests {
+		p := newTestGenAssetParam()
+		p.Total = test.total
+		err := p.Check(nil)
+		if test.err == "" && err != nil {
+			t.Errorf("total %v: unexpected error: %v", test.total, err)
+		}
+		if test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("total %v: error mismatch: have %v, want %q", test.total, err, test.err)
+		}
+	}
+}
+
+func TestGenAssetParamCheckNameSymbol(t *testing.T) {
+	nameErr := "GenAsset name must be printable and not blank"
+	symbolErr := "GenAsset symbol must be printable and not blank"
+	tests := []struct {
+		name, symbol string
+		err          string
+	}{
+		{"Test Asset", "TST", ""},
+		{"Ether", "Ξ", ""},
+		{"Test\nAsset", "TST", nameErr},
+		{"Test\tAsset", "TST", nameErr},
+		{"   ", "TST", nameErr},
+		{"Test Asset", "T\nST", symbolErr},
+		{"Test Asset", "\t", symbolErr},
+	}
+	for _, test := range tests {
+		p := newTestGenAssetParam()
+		p.Name, p.Symbol = test.name, test.symbol
+		err := p.Check(nil)
+		if test.err == "" && err != nil {
+			t.Errorf("%q/%q: unexpected error: %v", test.name, test.symbol, err)
+		}
+		if test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("%q/%q: error mismatch: have %v, want %q", test.name, test.symbol, err, test.err)
+		}
+	}
+}
+
+func TestBuyTicketParamLifetime(t *testing.T) {
+	const start = 1000
+	tests := []struct {
+		end uint64
+		ok  bool
+	}{
+		{start + MinTicketLifetime - 1, false},
+		{start + MinTicketLifetime, true},
+		{start + MaxTicketLifetime, true},
+		{start + MaxTicketLifetime + 1, false},
+	}
+	for _, test := range tests {
+		p := &BuyTicketParam{Start: start, End: test.end}
+		if d := p.Duration(); d != test.end-start {
+			t.Errorf("Duration() = %d, want %d", d, test.end-start)
+		}
+		err := p.Check(nil, 0)
+		if test.ok && err != nil {
+			t.Errorf("lifetime %d: unexpected error: %v", test.end-start, err)
+		}
+		if !test.ok && err == nil {
+			t.Errorf("lifetime %d: expected error", test.end-start)
+		}
+	}
+	if d := (&BuyTicketParam{Start: 2, End: 1}).Duration(); d != 0 {
+		t.Errorf("Duration() of inverted range = %d, want 0", d)
+	}
+}
+
+func TestParamCheckErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func() error
+		err   error
+	}{
+		{"GenAsset nil total", func() error {
+			p := newTestGenAssetParam()
+			p.Total = nil
+			return p.Check(nil)
+		}, ErrMissingField},
+		{"GenAsset decimals", func() error {
+			p := newTestGenAssetParam()
+			p.Decimals = 19
+			return p.Check(nil)
+		}, ErrInvalidDecimals},
+		{"GenAsset zero total", func() error {
+			p := newTestGenAssetParam()
+			p.Total = big.NewInt(0)
+			return p.Check(nil)
+		}, ErrZeroValue},
+		{"GenAsset description", func() error {
+			p := newTestGenAssetParam()
+			p.Description = strings.Repeat("a", 1025)
+			return p.Check(nil)
+		}, ErrDescriptionTooLong},
+		{"SendAsset zero value", func() error {
+			p := &SendAssetParam{AssetID: SystemAssetID, To: HexToAddress("0x01"), Value: big.NewInt(0)}
+			return p.Check(nil)
+		}, ErrZeroValue},
+		{"SendAsset zero address", func() error {
+			p := &SendAssetParam{AssetID: SystemAssetID, Value: big.NewInt(1)}
+			return p.Check(nil)
+		}, ErrZeroAddress},
+		{"SendAsset zero asset", func() error {
+			p := &SendAssetParam{To: HexToAddress("0x01"), Value: big.NewInt(1)}
+			return p.Check(nil)
+		}, ErrZeroAssetID},
+		{"MakeSwap zero amount", func() error {
+			p := newTestMakeSwapParam()
+			p.MinToAmount = big.NewInt(0)
+			return p.Check(nil, 100)
+		}, ErrZeroValue},
+		{"MakeSwap time range", func() error {
+			p := newTestMakeSwapParam()
+			p.FromStartTime, p.FromEndTime = 2000, 1000
+			return p.Check(nil, 100)
+		}, ErrTimeRangeInvalid},
+		{"MakeSwap expired", func() error {
+			p := newTestMakeSwapParam()
+			p.ToEndTime = 100
+			return p.Check(nil, 100)
+		}, ErrTimeRangeInvalid},
+		{"MakeSwap description", func() error {
+			p := newTestMakeSwapParam()
+			p.Description = strings.Repeat("a", 1025)
+			return p.Check(nil, 100)
+		}, ErrDescriptionTooLong},
+		{"MakeSwap USAN", func() error {
+			p := newTestMakeSwapParam()
+			p.ToAssetID = OwnerUSANAssetID
+			return p.Check(nil, 100)
+		}, ErrNotSwappable},
+		{"MakeSwap targets", func() error {
+			p := newTestMakeSwapParam()
+			p.Targes = make([]Address, MaxSwapTargets+1)
+			return p.Check(nil, 100)
+		}, ErrTooManyTargets},
+	}
+	for _, test := range tests {
+		err := test.check()
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: error %v does not match %v", test.name, err, test.err)
+		}
+	}
+
+	// the descriptive messages are kept as is
+	p := &SendAssetParam{AssetID: SystemAssetID, Value: big.NewInt(1)}
+	if err := p.Check(nil); err.Error() != "receiver address must be set and not zero address" {
+		t.Errorf("unexpected error message: %q", err)
+	}
+}
+
+// TestParamEncodingHash pins the canonical encoding of the param types.
+// A failure here means the RLP layout changed, which breaks consensus.
+func TestParamEncodingHash(t *testing.T) {
+	tests := []struct {
+		name string
+		hash Hash
+		exp  string
+	}{
+		{"FSNCallParam", (&FSNCallParam{Func: SendAssetFunc, Data: []byte{1, 2, 3}}).EncodingHash(), "0x53c03f1d98964aae78845496c8fa16d976e2aa91ec1768c6bb867289b2fe8cd4"},
+		{"GenAssetParam", (&GenAssetParam{Name: "Test Asset", Symbol: "TST", Decimals: 18, Total: big.NewInt(1000000)}).EncodingHash(), "0x54651d98768a7b8c6c75d1ded268fb769b11e394bfbc5c7f293e1e7ab25e7160"},
+		{"SendAssetParam", (&SendAssetParam{AssetID: SystemAssetID, To: HexToAddress("0x01"), Value: big.NewInt(5)}).EncodingHash(), "0x8031a14fd46515a0bd6691c46d97fce08df93325418887a9073d109c9e29dd27"},
+		{"TimeLockParam", (&TimeLockParam{Type: SmartTransfer, AssetID: SystemAssetID, To: HexToAddress("0x01"), StartTime: 100, EndTime: 200, Value: big.NewInt(5)}).EncodingHash(), "0x20dd6262df7d59c88dcf71718a067c0d231d6147b4f73cc670b11795f815f218"},
+		{"BuyTicketParam", (&BuyTicketParam{Start: 100, End: 200}).EncodingHash(), "0xaea394c00672d44551483e6350816cb0f464f9c66ca7d0f9b63ccaad8e885f23"},
+		{"AssetValueChangeExParam", (&AssetValueChangeExParam{AssetID: SystemAssetID, To: HexToAddress("0x01"), Value: big.NewInt(5), IsInc: true, TransacData: "data"}).EncodingHash(), "0x3cea1faca8eca023ec37985600c4d36a66b99018dc8d1eb65a3afdc092297e76"},
+		{"MakeSwapParam", (&MakeSwapParam{
+			FromAssetID:   SystemAssetID,
+			FromEndTime:   TimeLockForever,
+			MinFromAmount: big.NewInt(1),
+			ToAssetID:     HexToHash("0x01"),
+			ToEndTime:     TimeLockForever,
+			MinToAmount:   big.NewInt(2),
+			SwapSize:      big.NewInt(3),
+			Targes:        []Address{HexToAddress("0x02")},
+			Time:          big.NewInt(4),
+			Description:   "swap",
+		}).EncodingHash(), "0x844fd81875b18bbc76e5e599652fefcec189907175b4080d21b0bcce7a46edcd"},
+		{"RecallSwapParam", (&RecallSwapParam{SwapID: HexToHash("0x01")}).EncodingHash(), "0x850ad17573abf60b6bf315c965fc617b5e1b7ca1dd1259865db797a756e1fc3b"},
+		{"TakeSwapParam", (&TakeSwapParam{SwapID: HexToHash("0x01"), Size: big.NewInt(2)}).EncodingHash(), "0xb0d3fe56c1d0e30b41aee81333fd9d40b025ed7972a455b30bf7373df736c4cb"},
+	}
+	for _, test := range tests {
+		if test.hash.Hex() != test.exp {
+			t.Errorf("%s: encoding hash mismatch: have %s, want %s", test.name, test.hash.Hex(), test.exp)
+		}
+	}
+}
+
+func TestParamCheckValue(t *testing.T) {
+	values := []struct {
+		v  *big.Int
+		ok bool
+	}{
+		{nil, false},
+		{big.NewInt(0), false},
+		{big.NewInt(-1), false},
+		{big.NewInt(1), true},
+	}
+	for _, value := range values {
+		if isPositive(value.v) != value.ok {
+			t.Errorf("isPositive(%v) = %v, want %v", value.v, !value.ok, value.ok)
+		}
+		checks := map[string]error{
+			"SendAsset":          (&SendAssetParam{AssetID: SystemAssetID, To: HexToAddress("0x01"), Value: value.v}).Check(nil),
+			"TimeLock":           (&TimeLockParam{StartTime: 100, EndTime: 200, Value: value.v}).Check(nil, 100),
+			"AssetValueChangeEx": (&AssetValueChangeExParam{AssetID: SystemAssetID, To: HexToAddress("0x01"), Value: value.v}).Check(nil),
+		}
+		for name, err := range checks {
+			if value.ok && err != nil {
+				t.Errorf("%s value %v: unexpected error: %v", name, value.v, err)
+			}
+			if !value.ok && !errors.Is(err, ErrZeroValue) {
+				t.Errorf("%s value %v: error %v does not match %v", name, value.v, err, ErrZeroValue)
+			}
+		}
+	}
+}
+
+func TestTimeLockParamCheckDuration(t *testing.T) {
+	const now = 1000000
+	tests := []struct {
+		start, end uint64
+		ok         bool
+	}{
+		{now, now + MaxTimeLockDuration, true},
+		{now, now + MaxTimeLockDuration + 1, false},
+		{now, TimeLockForever, true},
+		{TimeLockNow, TimeLockForever, true},
+		// a start far in the past counts from the latest block time
+		{1, now + MaxTimeLockDuration, true},
+		{1, now + MaxTimeLockDuration + 1, false},
+	}
+	for _, test := range tests {
+		p := &TimeLockParam{StartTime: test.start, EndTime: test.end, Value: big.NewInt(1)}
+		err := p.Check(nil, now)
+		if test.ok && err != nil {
+			t.Errorf("[%d, %d]: unexpected error: %v", test.start, test.end, err)
+		}
+		if !test.ok && (err == nil || err.Error() != "TimeLock duration exceeds maximum") {
+			t.Errorf("[%d, %d]: unexpected error: %v", test.start, test.end, err)
+		}
+	}
+}
+
+func TestAssetValueChangeExParamCheck(t *testing.T) {
+	valid := func() *AssetValueChangeExParam {
+		return &AssetValueChangeExParam{AssetID: SystemAssetID, To: HexToAddress("0x01"), Value: big.NewInt(1), IsInc: true}
+	}
+	if err := valid().Check(nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	p := valid()
+	p.To = Address{}
+	if err := p.Check(nil); !errors.Is(err, ErrZeroAddress) || err.Error() != "receiver address must be set" {
+		t.Errorf("zero To: unexpected error: %v", err)
+	}
+	p = valid()
+	p.Value = big.NewInt(0)
+	if err := p.Check(nil); !errors.Is(err, ErrZeroValue) {
+		t.Errorf("zero Value: unexpected error: %v", err)
+	}
+	p = valid()
+	p.TransacData = strings.Repeat("a", 257)
+	if err := p.Check(nil); !errors.Is(err, ErrDataTooLong) {
+		t.Errorf("long TransacData: unexpected error: %v", err)
+	}
+}
+
+func TestFSNCallParamMarshalJSON(t *testing.T) {
+	sendAsset := &SendAssetParam{AssetID: SystemAssetID, To: HexToAddress("0x01"), Value: big.NewInt(5)}
+	data, err := sendAsset.ToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		call FSNCallParam
+		exp  string
+	}{
+		{
+			FSNCallParam{Func: SendAssetFunc, Data: data},
+			`{"func":"SendAssetFunc","params":{"assetID":"0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff","to":"0x0000000000000000000000000000000000000001","value":5}}`,
+		},
+		{
+			FSNCallParam{Func: SendAssetFunc, Data: []byte{0x01, 0x02}},
+			`{"func":"SendAssetFunc","data":"0x0102"}`,
+		},
+		{
+			FSNCallParam{Func: 42, Data: []byte{0x01}},
+			`{"func":"FSNCallFunc(42)","data":"0x01"}`,
+		},
+	}
+	for _, test := range tests {
+		enc, err := json.Marshal(&test.call)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(enc) != test.exp {
+			t.Errorf("JSON mismatch:\nhave %s\nwant %s", enc, test.exp)
+		}
+	}
+}
+
+func TestParamJSONKeys(t *testing.T) {
+	swap := []string{"fromAssetID", "fromStartTime", "fromEndTime", "minFromAmount", "toAssetID", "toStartTime",
+		"toEndTime", "minToAmount", "swapSize", "targes", "time", "description"}
+	tests := []struct {
+		param interface{}
+		keys  []string
+	}{
+		{&GenNotationParam{}, nil},
+		{&GenAssetParam{}, []string{"name", "symbol", "decimals", "total", "canChange", "description"}},
+		{&BuyTicketParam{}, []string{"start", "end"}},
+		{&SendAssetParam{}, []string{"assetID", "to", "value"}},
+		{&AssetValueChangeExParam{}, []string{"assetID", "to", "value", "isInc", "transacData"}},
+		{&TimeLockParam{}, []string{"type", "assetID", "to", "startTime", "endTime", "value"}},
+		{&MakeSwapParam{}, swap},
+		{&MakeMultiSwapParam{}, swap},
+		{&RecallSwapParam{}, []string{"swapID"}},
+		{&RecallMultiSwapParam{}, []string{"swapID"}},
+		{&TakeSwapParam{}, []string{"swapID", "size"}},
+		{&TakeMultiSwapParam{}, []string{"swapID", "size"}},
+	}
+	for _, test := range tests {
+		enc, err := json.Marshal(test.param)
+		if err != nil {
+			t.Fatalf("%T: %v", test.param, err)
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(enc, &fields); err != nil {
+			t.Fatalf("%T: %v", test.param, err)
+		}
+		if len(fields) != len(test.keys) {
+			t.Errorf("%T: have %d keys, want %d: %s", test.param, len(fields), len(test.keys), enc)
+		}
+		for _, key := range test.keys {
+			if _, ok := fields[key]; !ok {
+				t.Errorf("%T: missing key %q: %s", test.param, key, enc)
+			}
+		}
+	}
+}
+
+func TestParamJSONRoundTrip(t *testing.T) {
+	p := newTestMakeSwapParam()
+	p.Targes = []Address{HexToAddress("0x01")}
+	p.Time = big.NewInt(1234)
+	p.Description = "swap"
+	enc, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec MakeSwapParam
+	if err := json.Unmarshal(enc, &dec); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&dec, p) {
+		t.Errorf("MakeSwapParam mismatch: have %+v, want %+v", &dec, p)
+	}
+
+	// the JSON tags do not change the RLP encoding
+	want, _ := rlp.EncodeToBytes([]interface{}{p.FromAssetID, p.FromStartTime, p.FromEndTime, p.MinFromAmount,
+		p.ToAssetID, p.ToStartTime, p.ToEndTime, p.MinToAmount, p.SwapSize, p.Targes, p.Time, p.Description})
+	if have, _ := p.ToBytes(); !bytes.Equal(have, want) {
+		t.Errorf("RLP encoding changed:\nhave %x\nwant %x", have, want)
+	}
+}
+
+func TestParamJSONAmounts(t *testing.T) {
+	tests := []struct {
+		param interface{}
+		want  string
+	}{
+		{&SendAssetParam{Value: big.NewInt(5)}, `"value":5`},
+		{&TakeSwapParam{Size: big.NewInt(7)}, `"size":7`},
+		{&MakeMultiSwapParam{MinFromAmount: []*big.Int{big.NewInt(1), big.NewInt(2)}}, `"minFromAmount":[1,2]`},
+	}
+	for _, test := range tests {
+		enc, err := json.Marshal(test.param)
+		if err != nil {
+			t.Fatalf("%T: %v", test.param, err)
+		}
+		if !strings.Contains(string(enc), test.want) {
+			t.Errorf("%T: amount not encoded as number, want %s in %s", test.param, test.want, enc)
+		}
+	}
+}
+
+func TestMakeSwapParamToSwap(t *testing.T) {
+	p := newTestMakeSwapParam()
+	p.Targes = []Address{HexToAddress("0x01"), HexToAddress("0x02")}
+	p.Time = big.NewInt(1234)
+	p.Description = "swap"
+	id, owner := HexToHash("0x03"), HexToAddress("0x04")
+
+	swap := p.ToSwap(id, owner)
+	if swap.ID != id || swap.Owner != owner {
+		t.Errorf("ID/Owner mismatch: have %x/%x, want %x/%x", swap.ID, swap.Owner, id, owner)
+	}
+	if swap.FromAssetID != p.FromAssetID || swap.ToAssetID != p.ToAssetID ||
+		swap.FromEndTime != p.FromEndTime || swap.ToEndTime != p.ToEndTime ||
+		swap.MinFromAmount.Cmp(p.MinFromAmount) != 0 || swap.MinToAmount.Cmp(p.MinToAmount) != 0 ||
+		swap.SwapSize.Cmp(p.SwapSize) != 0 || swap.Description != p.Description {
+		t.Errorf("swap mismatch: have %+v, param %+v", swap, p)
+	}
+	if !reflect.DeepEqual(swap.Targes, p.Targes) || swap.Time.Cmp(p.Time) != 0 {
+		t.Fatalf("targets/time mismatch: have %v/%v, want %v/%v", swap.Targes, swap.Time, p.Targes, p.Time)
+	}
+
+	p.Targes[0] = HexToAddress("0x05")
+	p.Targes = append(p.Targes, HexToAddress("0x06"))
+	p.Time.SetInt64(1)
+	if len(swap.Targes) != 2 || swap.Targes[0] != HexToAddress("0x01") {
+		t.Errorf("swap targets changed with param: %v", swap.Targes)
+	}
+	if swap.Time.Int64() != 1234 {
+		t.Errorf("swap time changed with param: %v", swap.Time)
+	}
+}
+
+func TestRecallSwapParamCheck(t *testing.T) {
+	owner := HexToAddress("0x01")
+	swap := &Swap{ID: HexToHash("0x02"), Owner: owner}
+	p := &RecallSwapParam{SwapID: swap.ID}
+	if err := p.Check(nil, swap, owner); err != nil {
+		t.Errorf("owner recall: unexpected error: %v", err)
+	}
+	err := p.Check(nil, swap, HexToAddress("0x03"))
+	if !errors.Is(err, ErrNotSwapOwner) || err.Error() != "only swap owner can recall" {
+		t.Errorf("non-owner recall: unexpected error: %v", err)
+	}
+}
+
+func TestMakeSwapParamEncodedSize(t *testing.T) {
+	manyTarges := make([]Address, 300)
+	for i := range manyTarges {
+		manyTarges[i] = BigToAddress(big.NewInt(int64(i + 1)))
+	}
+	large, _ := new(big.Int).SetString("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", 0)
+	tests := []*MakeSwapParam{
+		{},
+		newTestMakeSwapParam(),
+		{MinFromAmount: big.NewInt(0x7f), MinToAmount: big.NewInt(0x80), SwapSize: big.NewInt(0x100), Time: large},
+		{FromStartTime: 0x7f, FromEndTime: 0x80, ToStartTime: 0xffff, ToEndTime: TimeLockForever},
+		{Targes: []Address{}, Description: "a"},
+		{Targes: manyTarges, Description: "\xff"},
+		{Description: strings.Repeat("x", 55)},
+		{Description: strings.Repeat("x", 56)},
+		{Description: strings.Repeat("x", 1024)},
+	}
+	for i, p := range tests {
+		enc, err := p.ToBytes()
+		if err != nil {
+			t.Fatalf("test %d: encoding failed: %v", i, err)
+		}
+		if size := p.EncodedSize(); size != len(enc) {
+			t.Errorf("test %d: EncodedSize() = %d, want %d", i, size, len(enc))
+		}
+	}
+}
+
+func TestParamError(t *testing.T) {
+	swap := newTestMakeSwapParam().ToSwap(HexToHash("0x01"), HexToAddress("0x02"))
+	tests := []struct {
+		name  string
+		err   error
+		fn    FSNCallFunc
+		field string
+		cause error
+	}{
+		{"GenAsset symbol", (&GenAssetParam{Name: "Test", Total: big.NewInt(1)}).Check(nil), GenAssetFunc, "Symbol", ErrMissingField},
+		{"GenAsset decimals", (&GenAssetParam{Name: "Test", Symbol: "TST", Decimals: 19, Total: big.NewInt(1)}).Check(nil), GenAssetFunc, "Decimals", ErrInvalidDecimals},
+		{"SendAsset to", (&SendAssetParam{AssetID: SystemAssetID, Value: big.NewInt(1)}).Check(nil), SendAssetFunc, "To", ErrZeroAddress},
+		{"TimeLock end", (&TimeLockParam{StartTime: 100, EndTime: 200, Value: big.NewInt(1)}).Check(nil, 300), TimeLockFunc, "EndTime", ErrTimeRangeInvalid},
+		{"BuyTicket start", (&BuyTicketParam{Start: 1e6, End: 1e6 + MinTicketLifetime}).Check(nil, 1), BuyTicketFunc, "Start", ErrTimeRangeInvalid},
+		{"MakeSwap size", (&MakeSwapParam{MinFromAmount: big.NewInt(1), MinToAmount: big.NewInt(1)}).Check(nil, 0), MakeSwapFunc, "SwapSize", ErrZeroValue},
+		{"TakeSwap size", (&TakeSwapParam{Size: big.NewInt(0)}).Check(nil, &swap, 0), TakeSwapFunc, "Size", ErrInvalidSize},
+		{"MakeMultiSwap amounts", (&MakeMultiSwapParam{}).Check(nil, 0), MakeMultiSwapFunc, "MinFromAmount", ErrMissingField},
+	}
+	for _, test := range tests {
+		var perr *ParamError
+		if !errors.As(test.err, &perr) {
+			t.Errorf("%s: error %v is not a *ParamError", test.name, test.err)
+			continue
+		}
+		if perr.Func != test.fn || perr.Field != test.field {
+			t.Errorf("%s: have %v.%s, want %v.%s", test.name, perr.Func, perr.Field, test.fn, test.field)
+		}
+		if !errors.Is(test.err, test.cause) {
+			t.Errorf("%s: error %v does not match %v", test.name, test.err, test.cause)
+		}
+	}
+
+	err := &ParamError{Func: SendAssetFunc, Field: "Value", Err: ErrZeroValue}
+	if have, want := err.Error(), "SendAssetFunc Value: value must be greater than 0"; have != want {
+		t.Errorf("Error() = %q, want %q", have, want)
+	}
+}
+
+func TestMakeSwapParamCheckTotalBits(t *testing.T) {
+	// 2^128 * (2^128 - 1) fits in 256 bits, 2^128 * 2^128 does not
+	under := new(big.Int).Sub(new(big.Int).Lsh(Big1, 128), Big1)
+	over := new(big.Int).Lsh(Big1, 128)
+	size := new(big.Int).Lsh(Big1, 128)
+	tests := []struct {
+		from, to *big.Int
+		msg      string
+	}{
+		{under, under, ""},
+		{over, under, "size * MinFromAmount exceeds 256 bits"},
+		{under, over, "size * MinToAmount exceeds 256 bits"},
+	}
+	for i, test := range tests {
+		p := newTestMakeSwapParam()
+		p.MinFromAmount, p.MinToAmount, p.SwapSize = test.from, test.to, size
+		err := p.Check(nil, 0)
+		if test.msg == "" {
+			if err != nil {
+				t.Errorf("test %d: unexpected error: %v", i, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != test.msg || !errors.Is(err, ErrValueTooLarge) {
+			t.Errorf("test %d: have error %v, want %q", i, err, test.msg)
+		}
+		// not enforced before the strict checking fork
+		if err := p.Check(Big0, 0); err != nil {
+			t.Errorf("test %d: unexpected error before fork: %v", i, err)
+		}
+	}
+}
+
+func TestMakeSwapParamCheckTargetList(t *testing.T) {
+	a1, a2 := HexToAddress("0x01"), HexToAddress("0x02")
+	tests := []struct {
+		targes []Address
+		err    error
+	}{
+		{nil, nil},
+		{[]Address{a1, a2}, nil},
+		{[]Address{a1, a2, a1}, ErrDuplicateTarget},
+		{[]Address{a1, {}}, ErrZeroAddress},
+	}
+	for i, test := range tests {
+		p := newTestMakeSwapParam()
+		p.Targes = test.targes
+		err := p.Check(nil, 0)
+		if test.err == nil && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if test.err != nil && !errors.Is(err, test.err) {
+			t.Errorf("test %d: have error %v, want %v", i, err, test.err)
+		}
+		if err := p.Check(Big0, 0); err != nil {
+			t.Errorf("test %d: unexpected error before fork: %v", i, err)
+		}
+
+		p.NormalizeTargets()
+		if err := p.Check(nil, 0); err != nil {
+			t.Errorf("test %d: error after NormalizeTargets: %v", i, err)
+		}
+	}
+
+	p := newTestMakeSwapParam()
+	p.Targes = []Address{a2, {}, a1, a2, {}, a1}
+	p.NormalizeTargets()
+	if want := []Address{a2, a1}; !reflect.DeepEqual(p.Targes, want) {
+		t.Errorf("NormalizeTargets: have %v, want %v", p.Targes, want)
+	}
+}
+
+func TestMakeSwapParamCheckDescription(t *testing.T) {
+	tests := []struct {
+		desc   string
+		err    error
+		strict bool // only rejected after the strict checking fork
+	}{
+		{"", nil, false},
+		{"swap\x00desc", ErrInvalidDescription, true},
+		{"line one\nline two", ErrInvalidDescription, true},
+		{strings.Repeat("a", 1024), nil, false},
+		{strings.Repeat("a", 1025), ErrDescriptionTooLong, false},
+	}
+	for i, test := range tests {
+		p := newTestMakeSwapParam()
+		p.Description = test.desc
+		err := p.Check(nil, 0)
+		if test.err == nil && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if test.err != nil && !errors.Is(err, test.err) {
+			t.Errorf("test %d: have error %v, want %v", i, err, test.err)
+		}
+		err = p.Check(Big0, 0)
+		if (test.err == nil || test.strict) && err != nil {
+			t.Errorf("test %d: unexpected error before fork: %v", i, err)
+		}
+		if test.err != nil && !test.strict && !errors.Is(err, test.err) {
+			t.Errorf("test %d: have error %v before fork, want %v", i, err, test.err)
+		}
+	}
+}
+
+func TestParamCheckRuneLimits(t *testing.T) {
+	// "世" is 3 bytes, limits are counted in runes after the strict checking fork
+	multi := func(n int) string { return strings.Repeat("世", n) }
+	tests := []struct {
+		name  string
+		limit int
+		err   error
+		check func(s string, blockNumber *big.Int) error
+	}{
+		{"GenAsset.Name", 128, ErrInvalidName, func(s string, blockNumber *big.Int) error {
+			p := newTestGenAssetParam()
+			p.Name = s
+			return p.Check(blockNumber)
+		}},
+		{"GenAsset.Symbol", 64, ErrInvalidSymbol, func(s string, blockNumber *big.Int) error {
+			p := newTestGenAssetParam()
+			p.Symbol = s
+			return p.Check(blockNumber)
+		}},
+		{"GenAsset.Description", 1024, ErrDescriptionTooLong, func(s string, blockNumber *big.Int) error {
+			p := newTestGenAssetParam()
+			p.Description = s
+			return p.Check(blockNumber)
+		}},
+		{"MakeSwap.Description", 1024, ErrDescriptionTooLong, func(s string, blockNumber *big.Int) error {
+			p := newTestMakeSwapParam()
+			p.Description = s
+			return p.Check(blockNumber, 0)
+		}},
+	}
+	for _, test := range tests {
+		if err := test.check(multi(test.limit), nil); err != nil {
+			t.Errorf("%s: unexpected error at %d runes: %v", test.name, test.limit, err)
+		}
+		if err := test.check(multi(test.limit+1), nil); !errors.Is(err, test.err) {
+			t.Errorf("%s: have error %v at %d runes, want %v", test.name, err, test.limit+1, test.err)
+		}
+		// bytes are counted before the fork
+		if err := test.check(multi(test.limit), Big0); !errors.Is(err, test.err) {
+			t.Errorf("%s: have error %v before fork, want %v", test.name, err, test.err)
+		}
+		if err := test.check(strings.Repeat("a", test.limit), Big0); err != nil {
+			t.Errorf("%s: unexpected error before fork: %v", test.name, err)
+		}
+	}
+}
+
+func TestAssetValueChangeExParamTransacBytes(t *testing.T) {
+	data := []byte{0x00, 0xff, 0x00, 0x80, 'a', 0x00}
+	p := &AssetValueChangeExParam{AssetID: SystemAssetID, To: HexToAddress("0x01"), Value: big.NewInt(1)}
+	p.SetTransacBytes(data)
+	enc, err := p.ToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec AssetValueChangeExParam
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dec.TransacBytes(), data) {
+		t.Errorf("TransacBytes = %x, want %x", dec.TransacBytes(), data)
+	}
+
+	// the limit is counted in bytes
+	p.SetTransacBytes(bytes.Repeat([]byte{0xe4}, 256))
+	if err := p.Check(nil); err != nil {
+		t.Errorf("unexpected error at 256 bytes: %v", err)
+	}
+	p.SetTransacBytes(make([]byte, 257))
+	if err := p.Check(nil); !errors.Is(err, ErrDataTooLong) {
+		t.Errorf("have error %v at 257 bytes, want %v", err, ErrDataTooLong)
+	}
+	p.SetTransacBytes([]byte(strings.Repeat("世", 86)))
+	if err := p.Check(nil); !errors.Is(err, ErrDataTooLong) {
+		t.Errorf("have error %v for 258 byte multibyte data, want %v", err, ErrDataTooLong)
+	}
+}
+
+func TestMakeSwapParamMatches(t *testing.T) {
+	other := func(fromAmount, toAmount int64) *MakeSwapParam {
+		p := newTestMakeSwapParam()
+		p.FromAssetID, p.ToAssetID = p.ToAssetID, p.FromAssetID
+		p.MinFromAmount, p.MinToAmount = big.NewInt(fromAmount), big.NewInt(toAmount)
+		return p
+	}
+	// sell 2 FSN for 3 of asset 0x01
+	p := newTestMakeSwapParam()
+	p.MinFromAmount, p.MinToAmount = big.NewInt(2), big.NewInt(3)
+
+	tests := []struct {
+		other *MakeSwapParam
+		match bool
+	}{
+		{other(3, 2), true},  // same price
+		{other(4, 2), true},  // better price for both
+		{other(2, 2), false}, // too little offered
+		{other(3, 3), false}, // too much asked
+	}
+	for i, test := range tests {
+		if match := p.Matches(test.other); match != test.match {
+			t.Errorf("test %d: Matches = %v, want %v", i, match, test.match)
+		}
+		if match := test.other.Matches(p); match != test.match {
+			t.Errorf("test %d: reverse Matches = %v, want %v", i, match, test.match)
+		}
+	}
+
+	mismatched := other(3, 2)
+	mismatched.FromAssetID = HexToHash("0x02")
+	if p.Matches(mismatched) || mismatched.Matches(p) {
+		t.Error("swaps with mismatched assets match")
+	}
+	same := newTestMakeSwapParam()
+	if p.Matches(same) {
+		t.Error("swaps offering the same asset match")
+	}
+}
+
+func TestMulWithin256(t *testing.T) {
+	max := new(big.Int).Sub(new(big.Int).Lsh(Big1, 256), Big1)
+	tests := []struct {
+		a, b *big.Int
+		ok   bool
+	}{
+		{big.NewInt(2), big.NewInt(3), true},
+		{max, Big1, true},
+		{new(big.Int).Lsh(Big1, 128), new(big.Int).Lsh(Big1, 127), true},
+		{new(big.Int).Lsh(Big1, 128), new(big.Int).Lsh(Big1, 128), false},
+		{max, big.NewInt(2), false},
+	}
+	for i, test := range tests {
+		product, ok := mulWithin256(test.a, test.b)
+		if ok != test.ok {
+			t.Errorf("test %d: fits = %v, want %v", i, ok, test.ok)
+		}
+		if product.Cmp(new(big.Int).Mul(test.a, test.b)) != 0 {
+			t.Errorf("test %d: wrong product %v", i, product)
+		}
+		// big.Int does not wrap, so a sign check never detects overflow
+		if product.Sign() <= 0 {
+			t.Errorf("test %d: product of positive values not positive", i)
+		}
+	}
+}
+
+func TestTakeSwapParamCheckTotalBits(t *testing.T) {
+	// a swap made before the 256 bit limit was enforced
+	swap := &Swap{
+		FromEndTime:   TimeLockForever,
+		ToEndTime:     TimeLockForever,
+		MinFromAmount: new(big.Int).Lsh(Big1, 250),
+		MinToAmount:   big.NewInt(1),
+		SwapSize:      new(big.Int).Lsh(Big1, 10),
+	}
+	if err := (&TakeSwapParam{Size: big.NewInt(32)}).Check(nil, swap, 0); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err := (&TakeSwapParam{Size: big.NewInt(64)}).Check(nil, swap, 0)
+	if !errors.Is(err, ErrValueTooLarge) {
+		t.Errorf("have error %v, want %v", err, ErrValueTooLarge)
+	}
+	if err := (&TakeSwapParam{Size: big.NewInt(64)}).Check(Big0, swap, 0); err != nil {
+		t.Errorf("unexpected error before fork: %v", err)
+	}
+}
+
+func TestTimeLockParamOverlaps(t *testing.T) {
+	to := HexToAddress("0x01")
+	lock := func(asset Hash, to Address, start, end uint64) *TimeLockParam {
+		return &TimeLockParam{AssetID: asset, To: to, StartTime: start, EndTime: end, Value: big.NewInt(1)}
+	}
+	base := lock(SystemAssetID, to, 100, 199)
+	tests := []struct {
+		name     string
+		other    *TimeLockParam
+		overlaps bool
+	}{
+		{"adjacent after", lock(SystemAssetID, to, 200, 300), false},
+		{"adjacent before", lock(SystemAssetID, to, 0, 99), false},
+		{"overlapping end", lock(SystemAssetID, to, 150, 300), true},
+		{"overlapping start", lock(SystemAssetID, to, 0, 100), true},
+		{"contained", lock(SystemAssetID, to, 120, 130), true},
+		{"containing", lock(SystemAssetID, to, 0, TimeLockForever), true},
+		{"different asset", lock(HexToHash("0x02"), to, 100, 199), false},
+		{"different receiver", lock(SystemAssetID, HexToAddress("0x02"), 100, 199), false},
+		{"nil", nil, false},
+	}
+	for _, test := range tests {
+		if overlaps := base.Overlaps(test.other); overlaps != test.overlaps {
+			t.Errorf("%s: Overlaps = %v, want %v", test.name, overlaps, test.overlaps)
+		}
+		if test.other != nil && test.other.Overlaps(base) != test.overlaps {
+			t.Errorf("%s: Overlaps not symmetric", test.name)
+		}
+	}
+}
+
+func TestFSNCallParamVersionedEncoding(t *testing.T) {
+	param := &FSNCallParam{Func: SendAssetFunc, Data: []byte{1, 2, 3}}
+	legacy, err := param.ToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	v0, err := param.ToBytesV(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v0, legacy) {
+		t.Errorf("version 0 encoding %x differs from ToBytes %x", v0, legacy)
+	}
+	v1, err := param.ToBytesV(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v1[0] != 1 || !reflect.DeepEqual(v1[1:], legacy) {
+		t.Errorf("unexpected version 1 encoding %x", v1)
+	}
+
+	for version, enc := range [][]byte{legacy, v1} {
+		dec, v, err := DecodeFSNCallParamV(enc)
+		if err != nil {
+			t.Fatalf("version %d: decode error: %v", version, err)
+		}
+		if int(v) != version || !reflect.DeepEqual(dec, param) {
+			t.Errorf("version %d: decoded %+v with version %d", version, dec, v)
+		}
+	}
+
+	if _, err := param.ToBytesV(MaxFSNCallParamVersion + 1); err == nil {
+		t.Error("expected error encoding unsupported version")
+	}
+	for _, enc := range [][]byte{append([]byte{0}, legacy...), append([]byte{2}, legacy...), {1}, nil} {
+		if _, _, err := DecodeFSNCallParamV(enc); err == nil {
+			t.Errorf("%x: expected error", enc)
+		}
+	}
+}
+
+func TestValidateFSNCall(t *testing.T) {
+	const now = 1600000000
+	errAny := errors.New("any error")
+	call := func(fn FSNCallFunc, param interface{ ToBytes() ([]byte, error) }) *FSNCallParam {
+		data, err := param.ToBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &FSNCallParam{Func: fn, Data: data}
+	}
+	swapID := HexToHash("0x10")
+	swap := newTestMakeSwapParam().ToSwap(swapID, HexToAddress("0x01"))
+	swap.FromEndTime, swap.ToEndTime = now+100, now+100
+	getSwap := func(id Hash) (*Swap, error) {
+		if id != swapID {
+			return nil, errors.New("swap not found")
+		}
+		return &swap, nil
+	}
+	getNilSwap := func(Hash) (*Swap, error) { return nil, nil }
+	getNilMultiSwap := func(Hash) (*MultiSwap, error) { return nil, nil }
+
+	tests := []struct {
+		name string
+		call *FSNCallParam
+		ctx  ValidationContext
+		err  error // nil means valid, errAny matches any error
+	}{
+		{"SendAsset", call(SendAssetFunc, &SendAssetParam{AssetID: SystemAssetID, To: HexToAddress("0x02"), Value: big.NewInt(1)}),
+			ValidationContext{}, nil},
+		{"SendAsset zero value", call(SendAssetFunc, &SendAssetParam{AssetID: SystemAssetID, To: HexToAddress("0x02"), Value: big.NewInt(0)}),
+			ValidationContext{}, ErrZeroValue},
+		{"BuyTicket", call(BuyTicketFunc, &BuyTicketParam{Start: now, End: now + 40*24*3600}),
+			ValidationContext{Timestamp: now}, nil},
+		{"BuyTicket future start", call(BuyTicketFunc, &BuyTicketParam{Start: now + 4*3600, End: now + 40*24*3600}),
+			ValidationContext{Timestamp: now}, ErrTimeRangeInvalid},
+		{"TakeSwap", call(TakeSwapFunc, &TakeSwapParam{SwapID: swapID, Size: big.NewInt(1)}),
+			ValidationContext{Timestamp: now, GetSwap: getSwap}, nil},
+		{"TakeSwap expired", call(TakeSwapFunc, &TakeSwapParam{SwapID: swapID, Size: big.NewInt(1)}),
+			ValidationContext{Timestamp: now + 100, GetSwap: getSwap}, ErrSwapExpired},
+		{"TakeSwap unknown swap", call(TakeSwapFunc, &TakeSwapParam{SwapID: HexToHash("0x11"), Size: big.NewInt(1)}),
+			ValidationContext{Timestamp: now, GetSwap: getSwap}, ErrSwapNotFound},
+		{"TakeSwap nil swap", call(TakeSwapFunc, &TakeSwapParam{SwapID: swapID, Size: big.NewInt(1)}),
+			ValidationContext{Timestamp: now, GetSwap: getNilSwap}, ErrSwapNotFound},
+		{"RecallSwap", call(RecallSwapFunc, &RecallSwapParam{SwapID: swapID}),
+			ValidationContext{Caller: swap.Owner, GetSwap: getSwap}, nil},
+		{"RecallSwap nil swap", call(RecallSwapFunc, &RecallSwapParam{SwapID: swapID}),
+			ValidationContext{Caller: swap.Owner, GetSwap: getNilSwap}, ErrSwapNotFound},
+		{"RecallMultiSwap nil swap", call(RecallMultiSwapFunc, &RecallMultiSwapParam{SwapID: swapID}),
+			ValidationContext{GetMultiSwap: getNilMultiSwap}, ErrSwapNotFound},
+		{"TakeMultiSwap nil swap", call(TakeMultiSwapFunc, &TakeMultiSwapParam{SwapID: swapID, Size: big.NewInt(1)}),
+			ValidationContext{Timestamp: now, GetMultiSwap: getNilMultiSwap}, ErrSwapNotFound},
+		{"TakeSwap without lookup", call(TakeSwapFunc, &TakeSwapParam{SwapID: swapID, Size: big.NewInt(1)}),
+			ValidationContext{Timestamp: now}, errAny},
+		{"EmptyFunc", &FSNCallParam{Func: EmptyFunc}, ValidationContext{}, ErrInvalidType},
+	}
+	for _, test := range tests {
+		err := ValidateFSNCall(test.call, test.ctx)
+		switch {
+		case test.err == nil && err != nil:
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		case test.err == errAny && err == nil:
+			t.Errorf("%s: expected error", test.name)
+		case test.err != nil && test.err != errAny && !errors.Is(err, test.err):
+			t.Errorf("%s: have error %v, want %v", test.name, err, test.err)
+		}
+	}
+}
+
+type testSwapStore map[Hash]*Swap
+
+func (s testSwapStore) GetSwap(id Hash) (*Swap, error) {
+	swap, ok := s[id]
+	if !ok {
+		return nil, errors.New("unknown swap")
+	}
+	return swap, nil
+}
+
+func TestTakeSwapParamCheckWithStore(t *testing.T) {
+	const now = 1600000000
+	active := newTestMakeSwapParam().ToSwap(HexToHash("0x01"), HexToAddress("0x01"))
+	active.FromEndTime, active.ToEndTime = now+100, now+100
+	expired := newTestMakeSwapParam().ToSwap(HexToHash("0x02"), HexToAddress("0x01"))
+	expired.FromEndTime, expired.ToEndTime = now, now
+	store := testSwapStore{active.ID: &active, expired.ID: &expired}
+
+	tests := []struct {
+		id  Hash
+		err error
+	}{
+		{active.ID, nil},
+		{expired.ID, ErrSwapExpired},
+		{HexToHash("0x03"), ErrSwapNotFound},
+	}
+	for i, test := range tests {
+		p := &TakeSwapParam{SwapID: test.id, Size: big.NewInt(1)}
+		err := p.CheckWithStore(nil, store, now)
+		if test.err == nil && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if test.err != nil && !errors.Is(err, test.err) {
+			t.Errorf("test %d: have error %v, want %v", i, err, test.err)
+		}
+	}
+}
@@ -4,11 +4,31 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"reflect"
+	"strings"
 )
 
 // SystemAssetID wacom
 var SystemAssetID = HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
 
+// IsSystemAsset reports whether id identifies the native FSN asset.
+func IsSystemAsset(id Hash) bool {
+	return id == SystemAssetID
+}
+
+// IsSystemAsset reports whether h identifies the native FSN asset.
+func (h Hash) IsSystemAsset() bool {
+	return IsSystemAsset(h)
+}
+
+// AssetID derives an asset ID from its creator and nonce as the Keccak256
+// hash of their RLP encoding, the same way contract addresses are derived.
+// Assets generated on chain through GenAssetFunc are instead identified by
+// the hash of their transaction.
+func AssetID(creator Address, nonce uint64) Hash {
+	return rlpHash([]interface{}{creator, nonce})
+}
+
 // OwnerUSANAssetID wacom
 var OwnerUSANAssetID = HexToHash("0xfffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe")
 
@@ -38,6 +58,24 @@ var (
 	ReportKeyAddress = HexToAddress("0xfffffffffffffffffffffffffffffffffffffff8")
 )
 
+// SystemAddress returns the index'th reserved system address, which is all
+// 0xff bytes except for a last byte of 0xff - index. FSNCallAddress is
+// SystemAddress(0) and ReportKeyAddress is SystemAddress(7).
+func SystemAddress(index uint8) Address {
+	var a Address
+	for i := range a {
+		a[i] = 0xff
+	}
+	a[AddressLength-1] = 0xff - index
+	return a
+}
+
+// IsSystemAddress reports whether a is one of the reserved system addresses,
+// FSNCallAddress, TicketLogAddress or a special key address.
+func IsSystemAddress(a Address) bool {
+	return a == FSNCallAddress || a == TicketLogAddress || a.IsSpecialKeyAddress()
+}
+
 func (addr Address) IsSpecialKeyAddress() bool {
 	return addr == TicketKeyAddress ||
 		addr == NotationKeyAddress ||
@@ -99,46 +137,70 @@ const (
 	UnknownFunc = 0xff
 )
 
+// fsnCallFuncNames maps every defined FSNCallFunc to its name. It must be
+// kept in sync with the constant block above.
+var fsnCallFuncNames = map[FSNCallFunc]string{
+	GenNotationFunc:         "GenNotationFunc",
+	GenAssetFunc:            "GenAssetFunc",
+	SendAssetFunc:           "SendAssetFunc",
+	TimeLockFunc:            "TimeLockFunc",
+	BuyTicketFunc:           "BuyTicketFunc",
+	OldAssetValueChangeFunc: "OldAssetValueChangeFunc",
+	MakeSwapFunc:            "MakeSwapFunc",
+	RecallSwapFunc:          "RecallSwapFunc",
+	TakeSwapFunc:            "TakeSwapFunc",
+	EmptyFunc:               "EmptyFunc",
+	MakeSwapFuncExt:         "MakeSwapFuncExt",
+	TakeSwapFuncExt:         "TakeSwapFuncExt",
+	AssetValueChangeFunc:    "AssetValueChangeFunc",
+	MakeMultiSwapFunc:       "MakeMultiSwapFunc",
+	RecallMultiSwapFunc:     "RecallMultiSwapFunc",
+	TakeMultiSwapFunc:       "TakeMultiSwapFunc",
+	ReportIllegalFunc:       "ReportIllegalFunc",
+}
+
+// AllFSNCallFuncs returns every defined function code in order, excluding
+// the UnknownFunc placeholder.
+func AllFSNCallFuncs() []FSNCallFunc {
+	funcs := make([]FSNCallFunc, 0, ReportIllegalFunc+1)
+	for f := FSNCallFunc(GenNotationFunc); f <= ReportIllegalFunc; f++ {
+		funcs = append(funcs, f)
+	}
+	return funcs
+}
+
+// FSNCallFuncByName returns the function code with the given name, the
+// inverse of String for defined codes.
+func FSNCallFuncByName(name string) (FSNCallFunc, bool) {
+	for f, n := range fsnCallFuncNames {
+		if n == name {
+			return f, true
+		}
+	}
+	return UnknownFunc, false
+}
+
+// Name returns the name of the function, or "Unknown" if f is not defined.
 func (f FSNCallFunc) Name() string {
-	switch f {
-	case GenNotationFunc:
-		return "GenNotationFunc"
-	case GenAssetFunc:
-		return "GenAssetFunc"
-	case SendAssetFunc:
-		return "SendAssetFunc"
-	case TimeLockFunc:
-		return "TimeLockFunc"
-	case BuyTicketFunc:
-		return "BuyTicketFunc"
-	case OldAssetValueChangeFunc:
-		return "OldAssetValueChangeFunc"
-	case MakeSwapFunc:
-		return "MakeSwapFunc"
-	case RecallSwapFunc:
-		return "RecallSwapFunc"
-	case TakeSwapFunc:
-		return "TakeSwapFunc"
-	case EmptyFunc:
-		return "EmptyFunc"
-	case MakeSwapFuncExt:
-		return "MakeSwapFuncExt"
-	case TakeSwapFuncExt:
-		return "TakeSwapFuncExt"
-	case AssetValueChangeFunc:
-		return "AssetValueChangeFunc"
-	case MakeMultiSwapFunc:
-		return "MakeMultiSwapFunc"
-	case RecallMultiSwapFunc:
-		return "RecallMultiSwapFunc"
-	case TakeMultiSwapFunc:
-		return "TakeMultiSwapFunc"
-	case ReportIllegalFunc:
-		return "ReportIllegalFunc"
+	if name, ok := fsnCallFuncNames[f]; ok {
+		return name
 	}
 	return "Unknown"
 }
 
+// IsValid reports whether f is one of the defined function codes.
+func (f FSNCallFunc) IsValid() bool {
+	return f <= ReportIllegalFunc
+}
+
+// String implements fmt.Stringer.
+func (f FSNCallFunc) String() string {
+	if name, ok := fsnCallFuncNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("FSNCallFunc(%d)", uint8(f))
+}
+
 func IsFsnCall(to *Address) bool {
 	return to != nil && *to == FSNCallAddress
 }
@@ -173,6 +235,14 @@ func (p *GenAssetParam) ToAsset() Asset {
 	}
 }
 
+// ToAssetWithOwner returns the asset described by p with its ID and Owner set.
+func (p *GenAssetParam) ToAssetWithOwner(id Hash, owner Address) Asset {
+	asset := p.ToAsset()
+	asset.ID = id
+	asset.Owner = owner
+	return asset
+}
+
 // Asset wacom
 type Asset struct {
 	ID          Hash
@@ -180,22 +250,25 @@ type Asset struct {
 	Name        string
 	Symbol      string
 	Decimals    uint8
-	Total       *big.Int `json:",string"`
+	Total       *big.Int
 	CanChange   bool
 	Description string
 }
 
+// AssetJSON is the JSON representation of an Asset.
+type AssetJSON struct {
+	ID          Hash
+	Owner       Address
+	Name        string
+	Symbol      string
+	Decimals    uint8
+	Total       string
+	CanChange   bool
+	Description string `json:",omitempty"`
+}
+
 func (u *Asset) MarshalJSON() ([]byte, error) {
-	return json.Marshal(&struct {
-		ID          Hash
-		Owner       Address
-		Name        string
-		Symbol      string
-		Decimals    uint8
-		Total       string
-		CanChange   bool
-		Description string
-	}{
+	return json.Marshal(&AssetJSON{
 		ID:          u.ID,
 		Owner:       u.Owner,
 		Name:        u.Name,
@@ -207,6 +280,103 @@ func (u *Asset) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// CheckValueChangeAllowed returns an error if the total of the asset can
+// not be changed. AssetValueChangeFunc handlers must call it before changing
+// the asset.
+func (u *Asset) CheckValueChangeAllowed() error {
+	if !u.CanChange {
+		return fmt.Errorf("asset can't inc or dec")
+	}
+	return nil
+}
+
+// IsOwner reports whether addr owns the asset. An asset without owner, such
+// as SystemAsset, is not owned by anyone, not even the zero address.
+func (u *Asset) IsOwner(addr Address) bool {
+	return !u.Owner.IsZero() && u.Owner == addr
+}
+
+// CheckOwner returns an error if addr is not the asset's Owner. Unlike
+// IsOwner it compares plainly, a zero Owner matches the zero address, as
+// block processing has always done.
+func (u *Asset) CheckOwner(addr Address) error {
+	if u.Owner != addr {
+		return fmt.Errorf("can only be changed by owner")
+	}
+	return nil
+}
+
+// DeepCopy returns a copy of the asset that shares no memory with u.
+// A nil Total is preserved as nil.
+func (u *Asset) DeepCopy() Asset {
+	return Asset{
+		ID:          u.ID,
+		Owner:       u.Owner,
+		Name:        u.Name,
+		Symbol:      u.Symbol,
+		Decimals:    u.Decimals,
+		Total:       copyBig(u.Total),
+		CanChange:   u.CanChange,
+		Description: u.Description,
+	}
+}
+
+// FormatValue formats the raw amount raw of the asset as a decimal number
+// with u.Decimals fractional digits, trimming trailing zeros, e.g. 1.5 for
+// 1500000000000000000 with 18 decimals. A nil raw formats as "0".
+func (u *Asset) FormatValue(raw *big.Int) string {
+	if raw == nil {
+		return "0"
+	}
+	digits := new(big.Int).Abs(raw).String()
+	decimals := int(u.Decimals)
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	intPart := digits[:len(digits)-decimals]
+	fracPart := strings.TrimRight(digits[len(digits)-decimals:], "0")
+
+	res := intPart
+	if fracPart != "" {
+		res += "." + fracPart
+	}
+	if raw.Sign() < 0 {
+		res = "-" + res
+	}
+	return res
+}
+
+// ParseValue parses the decimal number s, e.g. "1.5", into the raw amount
+// of the asset. It is the inverse of FormatValue. s must be non-negative and
+// have at most u.Decimals fractional digits.
+func (u *Asset) ParseValue(s string) (*big.Int, error) {
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+		if fracPart == "" {
+			return nil, fmt.Errorf("invalid value %q", s)
+		}
+	}
+	if intPart == "" || !isDecimalDigits(intPart) || !isDecimalDigits(fracPart) {
+		return nil, fmt.Errorf("invalid value %q", s)
+	}
+	if len(fracPart) > int(u.Decimals) {
+		return nil, fmt.Errorf("value %q has more than %d decimals", s, u.Decimals)
+	}
+	fracPart += strings.Repeat("0", int(u.Decimals)-len(fracPart))
+	v, _ := new(big.Int).SetString(intPart+fracPart, 10)
+	return v, nil
+}
+
+func isDecimalDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 // SystemAsset wacom
 var SystemAsset = Asset{
 	Name:        "Fusion",
@@ -224,18 +394,211 @@ type Swap struct {
 	FromAssetID   Hash
 	FromStartTime uint64
 	FromEndTime   uint64
-	MinFromAmount *big.Int `json:",string"`
+	MinFromAmount *big.Int
 	ToAssetID     Hash
 	ToStartTime   uint64
 	ToEndTime     uint64
-	MinToAmount   *big.Int `json:",string"`
-	SwapSize      *big.Int `json:",string"`
+	MinToAmount   *big.Int
+	SwapSize      *big.Int
 	Targes        []Address
 	Time          *big.Int // Provides information for TIME
 	Description   string
 	Notation      uint64
 }
 
+// DeepCopy returns a copy of the swap that shares no memory with s.
+// Nil big.Int fields are preserved as nil.
+func (s *Swap) DeepCopy() Swap {
+	var targes []Address
+	if s.Targes != nil {
+		targes = make([]Address, len(s.Targes))
+		copy(targes, s.Targes)
+	}
+	return Swap{
+		ID:            s.ID,
+		Owner:         s.Owner,
+		FromAssetID:   s.FromAssetID,
+		FromStartTime: s.FromStartTime,
+		FromEndTime:   s.FromEndTime,
+		MinFromAmount: copyBig(s.MinFromAmount),
+		ToAssetID:     s.ToAssetID,
+		ToStartTime:   s.ToStartTime,
+		ToEndTime:     s.ToEndTime,
+		MinToAmount:   copyBig(s.MinToAmount),
+		SwapSize:      copyBig(s.SwapSize),
+		Targes:        targes,
+		Time:          copyBig(s.Time),
+		Description:   s.Description,
+		Notation:      s.Notation,
+	}
+}
+
+// SwapJSON is the JSON representation of a Swap. Amounts are decimal
+// strings so that they survive JSON number precision limits, and null if
+// not set.
+type SwapJSON struct {
+	ID            Hash
+	Owner         Address
+	FromAssetID   Hash
+	FromStartTime uint64
+	FromEndTime   uint64
+	MinFromAmount *string
+	ToAssetID     Hash
+	ToStartTime   uint64
+	ToEndTime     uint64
+	MinToAmount   *string
+	SwapSize      *string
+	Targes        []Address
+	Time          *string
+	Description   string `json:",omitempty"`
+	Notation      uint64
+}
+
+// bigToString returns the decimal string of v, or nil if v is nil.
+func bigToString(v *big.Int) *string {
+	if v == nil {
+		return nil
+	}
+	str := v.String()
+	return &str
+}
+
+// bigFromString parses the decimal string s of the named field. A nil s
+// yields nil.
+func bigFromString(field string, s *string) (*big.Int, error) {
+	if s == nil {
+		return nil, nil
+	}
+	v, ok := new(big.Int).SetString(*s, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s %q", field, *s)
+	}
+	return v, nil
+}
+
+func (s *Swap) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&SwapJSON{
+		ID:            s.ID,
+		Owner:         s.Owner,
+		FromAssetID:   s.FromAssetID,
+		FromStartTime: s.FromStartTime,
+		FromEndTime:   s.FromEndTime,
+		MinFromAmount: bigToString(s.MinFromAmount),
+		ToAssetID:     s.ToAssetID,
+		ToStartTime:   s.ToStartTime,
+		ToEndTime:     s.ToEndTime,
+		MinToAmount:   bigToString(s.MinToAmount),
+		SwapSize:      bigToString(s.SwapSize),
+		Targes:        s.Targes,
+		Time:          bigToString(s.Time),
+		Description:   s.Description,
+		Notation:      s.Notation,
+	})
+}
+
+func (s *Swap) UnmarshalJSON(input []byte) error {
+	var dec SwapJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	var amounts [4]*big.Int
+	for i, field := range []struct {
+		name  string
+		value *string
+	}{
+		{"MinFromAmount", dec.MinFromAmount},
+		{"MinToAmount", dec.MinToAmount},
+		{"SwapSize", dec.SwapSize},
+		{"Time", dec.Time},
+	} {
+		v, err := bigFromString(field.name, field.value)
+		if err != nil {
+			return err
+		}
+		amounts[i] = v
+	}
+	*s = Swap{
+		ID:            dec.ID,
+		Owner:         dec.Owner,
+		FromAssetID:   dec.FromAssetID,
+		FromStartTime: dec.FromStartTime,
+		FromEndTime:   dec.FromEndTime,
+		MinFromAmount: amounts[0],
+		ToAssetID:     dec.ToAssetID,
+		ToStartTime:   dec.ToStartTime,
+		ToEndTime:     dec.ToEndTime,
+		MinToAmount:   amounts[1],
+		SwapSize:      amounts[2],
+		Targes:        dec.Targes,
+		Time:          amounts[3],
+		Description:   dec.Description,
+		Notation:      dec.Notation,
+	}
+	return nil
+}
+
+// IsTargeted reports whether the swap is restricted to a list of takers.
+func (s *Swap) IsTargeted() bool {
+	return len(s.Targes) > 0
+}
+
+// IsExpired reports whether either side of the swap has ended at the given
+// timestamp, in which case the swap can no longer be taken.
+func (s *Swap) IsExpired(timestamp uint64) bool {
+	return s.FromEndTime <= timestamp || s.ToEndTime <= timestamp
+}
+
+// AllowsTaker reports whether addr may take the swap, i.e. the swap is open
+// to anyone or addr is one of its targets.
+func (s *Swap) AllowsTaker(addr Address) bool {
+	return CheckSwapTargets(s.Targes, addr) == nil
+}
+
+// TargetSet returns the targets of the swap as a set, for callers checking
+// many takers against a large target list. An open swap yields an empty set.
+func (s *Swap) TargetSet() AddressSet {
+	return NewAddressSet(s.Targes...)
+}
+
+// RemainingAfter returns the swap size left after taking the given size.
+func (s *Swap) RemainingAfter(taken *big.Int) (*big.Int, error) {
+	if s.SwapSize == nil || taken == nil {
+		return nil, fmt.Errorf("swap size and taken size must be set")
+	}
+	if taken.Cmp(s.SwapSize) > 0 {
+		return nil, fmt.Errorf("taken size %v exceeds swap size %v", taken, s.SwapSize)
+	}
+	return new(big.Int).Sub(s.SwapSize, taken), nil
+}
+
+// AmountsForSize returns the from and to amounts exchanged when taking the
+// given size of the swap.
+func (s *Swap) AmountsForSize(size *big.Int) (from *big.Int, to *big.Int, err error) {
+	if s.MinFromAmount == nil || s.MinToAmount == nil {
+		return nil, nil, fmt.Errorf("swap amounts must be set")
+	}
+	if _, err := s.RemainingAfter(size); err != nil {
+		return nil, nil, err
+	}
+	from = new(big.Int).Mul(s.MinFromAmount, size)
+	to = new(big.Int).Mul(s.MinToAmount, size)
+	return from, to, nil
+}
+
+// SwapSlice wacom
+type SwapSlice []Swap
+
+// FilterActive returns the swaps that are not expired at the given timestamp.
+func (s SwapSlice) FilterActive(timestamp uint64) SwapSlice {
+	var active SwapSlice
+	for i := range s {
+		if !s[i].IsExpired(timestamp) {
+			active = append(active, s[i])
+		}
+	}
+	return active
+}
+
 // MultiSwap wacom
 type MultiSwap struct {
 	ID            Hash
@@ -243,12 +606,12 @@ type MultiSwap struct {
 	FromAssetID   []Hash
 	FromStartTime []uint64
 	FromEndTime   []uint64
-	MinFromAmount []*big.Int `json:",string"`
+	MinFromAmount []*big.Int
 	ToAssetID     []Hash
 	ToStartTime   []uint64
 	ToEndTime     []uint64
-	MinToAmount   []*big.Int `json:",string"`
-	SwapSize      *big.Int   `json:",string"`
+	MinToAmount   []*big.Int
+	SwapSize      *big.Int
 	Targes        []Address
 	Time          *big.Int // Provides information for TIME
 	Description   string
@@ -277,3 +640,37 @@ type KeyValue struct {
 func NewKeyValue(name string, v interface{}) *KeyValue {
 	return &KeyValue{Key: name, Value: v}
 }
+
+// fsnCallLogParam is FSNCallParam without its MarshalJSON method. Logs are
+// part of the receipts, so a logged FSNCallParam must keep the default struct
+// encoding.
+type fsnCallLogParam FSNCallParam
+
+// FSNCallLogData returns the data of the receipt log for an FSNCall. value is
+// logged field by field if it is a struct and under "Base" otherwise.
+func FSNCallLogData(value interface{}, keyValues ...*KeyValue) []byte {
+	if param, ok := value.(*FSNCallParam); ok {
+		value = (*fsnCallLogParam)(param)
+	}
+
+	t := reflect.TypeOf(value)
+	v := reflect.ValueOf(value)
+
+	maps := make(map[string]interface{})
+	if t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			if v.Field(i).CanInterface() {
+				maps[t.Field(i).Name] = v.Field(i).Interface()
+			}
+		}
+	} else {
+		maps["Base"] = value
+	}
+
+	for i := 0; i < len(keyValues); i++ {
+		maps[keyValues[i].Key] = keyValues[i].Value
+	}
+
+	data, _ := json.Marshal(maps)
+	return data
+}
@@ -0,0 +1,622 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSwapDeepCopy(t *testing.T) {
+	swap := Swap{
+		ID:            HexToHash("0x01"),
+		MinFromAmount: big.NewInt(10),
+		SwapSize:      big.NewInt(3),
+		Targes:        []Address{HexToAddress("0x02")},
+	}
+	cpy := swap.DeepCopy()
+	if cpy.Time != nil || cpy.MinToAmount != nil {
+		t.Fatalf("nil fields not preserved: Time=%v MinToAmount=%v", cpy.Time, cpy.MinToAmount)
+	}
+	if cpy.ID != swap.ID || cpy.MinFromAmount.Cmp(swap.MinFromAmount) != 0 || cpy.SwapSize.Cmp(swap.SwapSize) != 0 {
+		t.Fatalf("copy mismatch: have %+v, want %+v", cpy, swap)
+	}
+	cpy.MinFromAmount.SetInt64(20)
+	cpy.Targes[0] = Address{}
+	if swap.MinFromAmount.Int64() != 10 || swap.Targes[0] != HexToAddress("0x02") {
+		t.Fatal("copy shares memory with the original swap")
+	}
+}
+
+func TestAssetDeepCopy(t *testing.T) {
+	asset := SystemAsset
+	cpy := asset.DeepCopy()
+	if cpy.Description != asset.Description || cpy.Description == "" {
+		t.Errorf("description mismatch: have %q, want %q", cpy.Description, asset.Description)
+	}
+	if cpy.Name != asset.Name || cpy.Symbol != asset.Symbol || cpy.Decimals != asset.Decimals || cpy.ID != asset.ID {
+		t.Errorf("copy mismatch: have %+v, want %+v", cpy, asset)
+	}
+	cpy.Total.SetInt64(1)
+	if asset.Total.Cmp(SystemAsset.Total) != 0 || SystemAsset.Total.Int64() == 1 {
+		t.Fatal("copy shares Total with the original asset")
+	}
+
+	asset.Total = nil
+	if cpy := asset.DeepCopy(); cpy.Total != nil {
+		t.Errorf("nil Total not preserved: have %v", cpy.Total)
+	}
+}
+
+func TestFSNCallFuncString(t *testing.T) {
+	tests := []struct {
+		f   FSNCallFunc
+		exp string
+	}{
+		{GenNotationFunc, "GenNotationFunc"},
+		{GenAssetFunc, "GenAssetFunc"},
+		{SendAssetFunc, "SendAssetFunc"},
+		{TimeLockFunc, "TimeLockFunc"},
+		{BuyTicketFunc, "BuyTicketFunc"},
+		{OldAssetValueChangeFunc, "OldAssetValueChangeFunc"},
+		{MakeSwapFunc, "MakeSwapFunc"},
+		{RecallSwapFunc, "RecallSwapFunc"},
+		{TakeSwapFunc, "TakeSwapFunc"},
+		{EmptyFunc, "EmptyFunc"},
+		{MakeSwapFuncExt, "MakeSwapFuncExt"},
+		{TakeSwapFuncExt, "TakeSwapFuncExt"},
+		{AssetValueChangeFunc, "AssetValueChangeFunc"},
+		{MakeMultiSwapFunc, "MakeMultiSwapFunc"},
+		{RecallMultiSwapFunc, "RecallMultiSwapFunc"},
+		{TakeMultiSwapFunc, "TakeMultiSwapFunc"},
+		{ReportIllegalFunc, "ReportIllegalFunc"},
+		{ReportIllegalFunc + 1, "FSNCallFunc(17)"},
+		{UnknownFunc, "FSNCallFunc(255)"},
+	}
+	for _, test := range tests {
+		if s := test.f.String(); s != test.exp {
+			t.Errorf("FSNCallFunc(%d).String() = %q, want %q", uint8(test.f), s, test.exp)
+		}
+	}
+	if len(fsnCallFuncNames) != ReportIllegalFunc+1 {
+		t.Errorf("have %d named FSNCallFuncs, want %d", len(fsnCallFuncNames), ReportIllegalFunc+1)
+	}
+}
+
+func TestAllFSNCallFuncs(t *testing.T) {
+	funcs := AllFSNCallFuncs()
+	if len(funcs) != len(fsnCallFuncNames) {
+		t.Fatalf("have %d funcs, want %d", len(funcs), len(fsnCallFuncNames))
+	}
+	for i, f := range funcs {
+		if uint8(f) != uint8(i) {
+			t.Errorf("funcs[%d] = %d, not in order", i, uint8(f))
+		}
+		g, ok := FSNCallFuncByName(f.String())
+		if !ok || g != f {
+			t.Errorf("FSNCallFuncByName(%q) = %d, %v", f.String(), uint8(g), ok)
+		}
+	}
+	for _, name := range []string{"", "Unknown", "FSNCallFunc(17)", "sendassetfunc"} {
+		if f, ok := FSNCallFuncByName(name); ok {
+			t.Errorf("FSNCallFuncByName(%q) = %d, expected no match", name, uint8(f))
+		}
+	}
+}
+
+func TestSwapAllowsTaker(t *testing.T) {
+	taker := HexToAddress("0x01")
+	other := HexToAddress("0x02")
+	open := Swap{}
+	targeted := Swap{Targes: []Address{other, taker}}
+
+	if open.IsTargeted() || !open.AllowsTaker(taker) {
+		t.Error("open swap should allow any taker")
+	}
+	if !targeted.IsTargeted() {
+		t.Error("targeted swap reported as open")
+	}
+	if !targeted.AllowsTaker(taker) {
+		t.Error("targeted swap should allow a listed taker")
+	}
+	if targeted.AllowsTaker(HexToAddress("0x03")) {
+		t.Error("targeted swap should reject an unlisted taker")
+	}
+	for _, swap := range []Swap{open, targeted} {
+		set := swap.TargetSet()
+		for _, addr := range []Address{taker, other, HexToAddress("0x03")} {
+			if set.Len() > 0 && set.Contains(addr) != swap.AllowsTaker(addr) {
+				t.Errorf("TargetSet disagrees with AllowsTaker for %x", addr)
+			}
+		}
+	}
+}
+
+func TestIsSystemAsset(t *testing.T) {
+	tests := []struct {
+		id  Hash
+		exp bool
+	}{
+		{SystemAssetID, true},
+		{OwnerUSANAssetID, false},
+		{HexToHash("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"), false},
+		{Hash{}, false},
+	}
+	for _, test := range tests {
+		if res := IsSystemAsset(test.id); res != test.exp {
+			t.Errorf("IsSystemAsset(%x) = %v, want %v", test.id, res, test.exp)
+		}
+		if res := test.id.IsSystemAsset(); res != test.exp {
+			t.Errorf("%x.IsSystemAsset() = %v, want %v", test.id, res, test.exp)
+		}
+	}
+}
+
+func TestGenAssetParamToAssetWithOwner(t *testing.T) {
+	p := &GenAssetParam{
+		Name:        "Test Asset",
+		Symbol:      "TST",
+		Decimals:    8,
+		Total:       big.NewInt(1000),
+		CanChange:   true,
+		Description: "test",
+	}
+	id, owner := HexToHash("0x01"), HexToAddress("0x02")
+	asset := p.ToAssetWithOwner(id, owner)
+	want := Asset{
+		ID:          id,
+		Owner:       owner,
+		Name:        p.Name,
+		Symbol:      p.Symbol,
+		Decimals:    p.Decimals,
+		Total:       p.Total,
+		CanChange:   p.CanChange,
+		Description: p.Description,
+	}
+	if !reflect.DeepEqual(asset, want) {
+		t.Errorf("asset mismatch: have %+v, want %+v", asset, want)
+	}
+}
+
+func TestSwapRemainingAfter(t *testing.T) {
+	tests := []struct {
+		size, taken *big.Int
+		remaining   *big.Int
+	}{
+		{big.NewInt(10), big.NewInt(10), big.NewInt(0)},
+		{big.NewInt(10), big.NewInt(4), big.NewInt(6)},
+		{big.NewInt(10), big.NewInt(11), nil},
+		{nil, big.NewInt(1), nil},
+		{big.NewInt(10), nil, nil},
+	}
+	for _, test := range tests {
+		swap := &Swap{SwapSize: test.size}
+		remaining, err := swap.RemainingAfter(test.taken)
+		if test.remaining == nil {
+			if err == nil {
+				t.Errorf("size %v taken %v: expected error", test.size, test.taken)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("size %v taken %v: unexpected error: %v", test.size, test.taken, err)
+		} else if remaining.Cmp(test.remaining) != 0 {
+			t.Errorf("size %v taken %v: remaining %v, want %v", test.size, test.taken, remaining, test.remaining)
+		}
+	}
+}
+
+func TestAssetMarshalJSON(t *testing.T) {
+	asset := SystemAsset
+	asset.Owner = HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")
+	enc, err := json.Marshal(&asset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{"ID":"0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",` +
+		`"Owner":"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed","Name":"Fusion","Symbol":"FSN",` +
+		`"Decimals":18,"Total":"81920000000000000000000000","CanChange":false,"Description":"https://fusion.org"}`
+	if string(enc) != exp {
+		t.Errorf("JSON mismatch:\nhave %s\nwant %s", enc, exp)
+	}
+}
+
+func TestMarshalJSONOmitsEmptyDescription(t *testing.T) {
+	asset := SystemAsset
+	asset.Description = ""
+	swap := &Swap{Description: ""}
+	for name, v := range map[string]json.Marshaler{"asset": &asset, "swap": swap} {
+		enc, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(enc), `"Description"`) {
+			t.Errorf("%s: empty Description not omitted: %s", name, enc)
+		}
+	}
+	asset.Description = "desc"
+	swap.Description = "desc"
+	for name, v := range map[string]json.Marshaler{"asset": &asset, "swap": swap} {
+		enc, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(enc), `"Description":"desc"`) {
+			t.Errorf("%s: Description missing: %s", name, enc)
+		}
+	}
+}
+
+func TestSwapAmountsForSize(t *testing.T) {
+	swap := &Swap{
+		MinFromAmount: big.NewInt(3),
+		MinToAmount:   big.NewInt(5),
+		SwapSize:      big.NewInt(10),
+		FromEndTime:   TimeLockForever,
+		ToEndTime:     TimeLockForever,
+	}
+	tests := []struct {
+		size     *big.Int
+		from, to int64
+		ok       bool
+	}{
+		{big.NewInt(1), 3, 5, true},
+		{big.NewInt(10), 30, 50, true},
+		{big.NewInt(11), 0, 0, false},
+		{nil, 0, 0, false},
+	}
+	for _, test := range tests {
+		from, to, err := swap.AmountsForSize(test.size)
+		if test.ok {
+			if err != nil {
+				t.Errorf("size %v: unexpected error: %v", test.size, err)
+			} else if from.Int64() != test.from || to.Int64() != test.to {
+				t.Errorf("size %v: amounts %v/%v, want %d/%d", test.size, from, to, test.from, test.to)
+			}
+		} else if err == nil {
+			t.Errorf("size %v: expected error", test.size)
+		}
+		// TakeSwapParam.Check agrees with AmountsForSize
+		if test.size != nil {
+			p := &TakeSwapParam{Size: test.size}
+			if err := p.Check(nil, swap, 100); (err == nil) != test.ok {
+				t.Errorf("size %v: Check error %v, want ok=%v", test.size, err, test.ok)
+			}
+		}
+	}
+
+	swap.MinToAmount = nil
+	if _, _, err := swap.AmountsForSize(big.NewInt(1)); err == nil {
+		t.Error("nil MinToAmount: expected error")
+	}
+	// Check only rejects nil amounts once strict param checking is enabled
+	p := &TakeSwapParam{Size: big.NewInt(1)}
+	if err := p.Check(Big0, swap, 100); err != nil {
+		t.Errorf("nil MinToAmount before fork: unexpected error %v", err)
+	}
+	if err := p.Check(nil, swap, 100); !errors.Is(err, ErrMissingField) {
+		t.Errorf("nil MinToAmount: Check error %v, want %v", err, ErrMissingField)
+	}
+}
+
+func TestAssetID(t *testing.T) {
+	// The low 20 bytes match the contract addresses crypto.CreateAddress
+	// derives for the same creator and nonce.
+	creator := HexToAddress("0x970e8128ab834e8eac17ab8e3812f010678cf791")
+	tests := []struct {
+		nonce uint64
+		exp   string
+	}{
+		{0, "0x8e6e1c44e90ca9343ae59bff333c3310824b7c685133f2bedb2ca4b8b4df633d"},
+		{1, "0x8441a7061350698189a09f938bda78331c916a08481428e4b07c96d3e916d165"},
+		{1 << 32, "0x148ce73dcdfb9dcbf4128266e6cc602f6b7efc02f5a1464be6c9542ed5fe0f99"},
+	}
+	for _, test := range tests {
+		if id := AssetID(creator, test.nonce); id.Hex() != test.exp {
+			t.Errorf("nonce %d: asset ID mismatch: have %s, want %s", test.nonce, id.Hex(), test.exp)
+		}
+	}
+	if AssetID(creator, 0) == AssetID(creator, 1) {
+		t.Error("different nonces derived the same asset ID")
+	}
+	if AssetID(creator, 0) == AssetID(HexToAddress("0x01"), 0) {
+		t.Error("different creators derived the same asset ID")
+	}
+}
+
+func TestSwapIsExpired(t *testing.T) {
+	const now = 1000
+	tests := []struct {
+		name     string
+		from, to uint64
+		expired  bool
+	}{
+		{"neither", now + 1, now + 1, false},
+		{"from side", now, now + 1, true},
+		{"to side", now + 1, now, true},
+		{"both", now - 1, now - 1, true},
+	}
+	var swaps SwapSlice
+	for i, test := range tests {
+		swap := Swap{ID: BigToHash(big.NewInt(int64(i))), FromEndTime: test.from, ToEndTime: test.to}
+		if expired := swap.IsExpired(now); expired != test.expired {
+			t.Errorf("%s: IsExpired = %v, want %v", test.name, expired, test.expired)
+		}
+		swaps = append(swaps, swap)
+	}
+	active := swaps.FilterActive(now)
+	if len(active) != 1 || active[0].ID != swaps[0].ID {
+		t.Errorf("FilterActive returned %v, want only the first swap", active)
+	}
+}
+
+func TestSwapJSON(t *testing.T) {
+	size, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	swap := &Swap{
+		ID:            HexToHash("0x01"),
+		Owner:         HexToAddress("0x02"),
+		FromAssetID:   SystemAssetID,
+		FromEndTime:   TimeLockForever,
+		MinFromAmount: big.NewInt(1),
+		ToAssetID:     HexToHash("0x03"),
+		ToStartTime:   100,
+		ToEndTime:     TimeLockForever,
+		MinToAmount:   big.NewInt(2),
+		SwapSize:      size,
+		Targes:        []Address{HexToAddress("0x04")},
+		Time:          big.NewInt(1500000000),
+		Description:   "swap",
+		Notation:      7,
+	}
+	enc, err := json.Marshal(swap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(enc), `"SwapSize":"123456789012345678901234567890"`) {
+		t.Errorf("SwapSize not encoded as exact decimal string: %s", enc)
+	}
+	var dec Swap
+	if err := json.Unmarshal(enc, &dec); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&dec, swap) {
+		t.Errorf("round trip mismatch:\nhave %+v\nwant %+v", dec, *swap)
+	}
+
+	enc, err = json.Marshal(&Swap{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(enc), `"MinFromAmount":null`) || !strings.Contains(string(enc), `"Time":null`) {
+		t.Errorf("nil amounts not encoded as null: %s", enc)
+	}
+	dec = Swap{}
+	if err := json.Unmarshal(enc, &dec); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&dec, &Swap{}) {
+		t.Errorf("nil amounts round trip mismatch: have %+v", dec)
+	}
+	zero := &Swap{MinFromAmount: new(big.Int), SwapSize: new(big.Int)}
+	enc, _ = json.Marshal(zero)
+	if err := json.Unmarshal(enc, &dec); err != nil {
+		t.Fatal(err)
+	}
+	if dec.MinFromAmount == nil || dec.MinFromAmount.Sign() != 0 || dec.SwapSize == nil || dec.MinToAmount != nil {
+		t.Errorf("zero amounts round trip mismatch: have %+v", dec)
+	}
+	if err := json.Unmarshal([]byte(`{"SwapSize":"1e3"}`), &dec); err == nil {
+		t.Error("expected error for malformed SwapSize")
+	}
+}
+
+func TestAssetFormatValue(t *testing.T) {
+	tests := []struct {
+		decimals uint8
+		raw      string
+		want     string
+	}{
+		{18, "1500000000000000000", "1.5"},
+		{18, "1000000000000000000", "1"},
+		{18, "1", "0.000000000000000001"},
+		{18, "0", "0"},
+		{18, "81920000000000000000000000", "81920000"},
+		{2, "12345", "123.45"},
+		{2, "5", "0.05"},
+		{2, "-150", "-1.5"},
+		{0, "12345", "12345"},
+		{0, "0", "0"},
+	}
+	for _, test := range tests {
+		asset := Asset{Decimals: test.decimals}
+		raw, _ := new(big.Int).SetString(test.raw, 10)
+		if have := asset.FormatValue(raw); have != test.want {
+			t.Errorf("FormatValue(%s) with %d decimals = %s, want %s", test.raw, test.decimals, have, test.want)
+		}
+	}
+	if have := SystemAsset.FormatValue(nil); have != "0" {
+		t.Errorf("FormatValue(nil) = %s, want 0", have)
+	}
+}
+
+func TestAssetParseValue(t *testing.T) {
+	tests := []struct {
+		decimals uint8
+		input    string
+		want     string
+	}{
+		{18, "1.5", "1500000000000000000"},
+		{18, "1", "1000000000000000000"},
+		{18, "0.000000000000000001", "1"},
+		{18, "0", "0"},
+		{2, "123.45", "12345"},
+		{2, "0.5", "50"},
+		{2, "007", "700"},
+		{0, "12345", "12345"},
+	}
+	for _, test := range tests {
+		asset := Asset{Decimals: test.decimals}
+		v, err := asset.ParseValue(test.input)
+		if err != nil {
+			t.Errorf("ParseValue(%q) with %d decimals: unexpected error: %v", test.input, test.decimals, err)
+			continue
+		}
+		if v.String() != test.want {
+			t.Errorf("ParseValue(%q) with %d decimals = %v, want %s", test.input, test.decimals, v, test.want)
+		}
+		if formatted := asset.FormatValue(v); test.input != "007" && formatted != test.input {
+			t.Errorf("FormatValue(ParseValue(%q)) = %s", test.input, formatted)
+		}
+	}
+
+	invalid := []struct {
+		decimals uint8
+		input    string
+	}{
+		{2, "1.234"},
+		{0, "1.5"},
+		{18, "-1"},
+		{18, ""},
+		{18, "."},
+		{18, "1."},
+		{18, ".5"},
+		{18, "1.2.3"},
+		{18, "abc"},
+		{18, "1e18"},
+		{18, " 1"},
+	}
+	for _, test := range invalid {
+		asset := Asset{Decimals: test.decimals}
+		if v, err := asset.ParseValue(test.input); err == nil {
+			t.Errorf("ParseValue(%q) with %d decimals = %v, expected error", test.input, test.decimals, v)
+		}
+	}
+}
+
+func TestAssetCheckValueChangeAllowed(t *testing.T) {
+	asset := Asset{CanChange: true}
+	if err := asset.CheckValueChangeAllowed(); err != nil {
+		t.Errorf("changeable asset: unexpected error: %v", err)
+	}
+	asset.CanChange = false
+	if err := asset.CheckValueChangeAllowed(); err == nil || err.Error() != "asset can't inc or dec" {
+		t.Errorf("immutable asset: unexpected error: %v", err)
+	}
+}
+
+func TestAssetOwner(t *testing.T) {
+	owner, other := HexToAddress("0x01"), HexToAddress("0x02")
+	asset := Asset{Owner: owner}
+	if !asset.IsOwner(owner) || asset.CheckOwner(owner) != nil {
+		t.Error("owner not recognized")
+	}
+	if asset.IsOwner(other) || asset.CheckOwner(other) == nil {
+		t.Error("non-owner accepted")
+	}
+	if asset.IsOwner(Address{}) {
+		t.Error("zero address accepted as owner")
+	}
+	// an asset without owner is owned by nobody, but CheckOwner keeps the
+	// plain comparison used in block processing
+	unowned := Asset{}
+	if unowned.IsOwner(Address{}) {
+		t.Error("zero address accepted as owner of unowned asset")
+	}
+	if unowned.CheckOwner(Address{}) != nil || unowned.CheckOwner(owner) == nil {
+		t.Error("CheckOwner does not compare plainly")
+	}
+	if err := asset.CheckOwner(other); err.Error() != "can only be changed by owner" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+var namedSystemAddresses = []struct {
+	name string
+	addr Address
+}{
+	{"FSNCallAddress", FSNCallAddress},
+	{"TicketLogAddress", TicketLogAddress},
+	{"NotationKeyAddress", NotationKeyAddress},
+	{"AssetKeyAddress", AssetKeyAddress},
+	{"TicketKeyAddress", TicketKeyAddress},
+	{"SwapKeyAddress", SwapKeyAddress},
+	{"MultiSwapKeyAddress", MultiSwapKeyAddress},
+	{"ReportKeyAddress", ReportKeyAddress},
+}
+
+func TestSystemAddress(t *testing.T) {
+	for i, named := range namedSystemAddresses {
+		if have := SystemAddress(uint8(i)); have != named.addr {
+			t.Errorf("SystemAddress(%d) = %x, want %s %x", i, have, named.name, named.addr)
+		}
+	}
+	if have, want := SystemAddress(0xff), HexToAddress("0xffffffffffffffffffffffffffffffffffffff00"); have != want {
+		t.Errorf("SystemAddress(0xff) = %x, want %x", have, want)
+	}
+}
+
+func TestIsSystemAddress(t *testing.T) {
+	for _, named := range namedSystemAddresses {
+		if !IsSystemAddress(named.addr) {
+			t.Errorf("%s %x not a system address", named.name, named.addr)
+		}
+	}
+	for _, addr := range []Address{
+		{},
+		HexToAddress("0x0123456789abcdef0123456789abcdef01234567"),
+		SystemAddress(uint8(len(namedSystemAddresses))), // just below the reserved range
+		HexToAddress("0x7fffffffffffffffffffffffffffffffffffffff"),
+	} {
+		if IsSystemAddress(addr) {
+			t.Errorf("%x reported as system address", addr)
+		}
+	}
+}
+
+// The log data is part of the receipts and so must never change.
+func TestFSNCallLogData(t *testing.T) {
+	genNotation := &FSNCallParam{Func: GenNotationFunc}
+	sendAsset := SendAssetParam{
+		AssetID: SystemAssetID,
+		To:      HexToAddress("0x01"),
+		Value:   big.NewInt(5),
+	}
+	tests := []struct {
+		value     interface{}
+		keyValues []*KeyValue
+		want      string
+	}{
+		{
+			genNotation,
+			[]*KeyValue{NewKeyValue("notation", uint64(100))},
+			`{"Base":{"Func":0,"Data":null},"notation":100}`,
+		},
+		{
+			genNotation,
+			[]*KeyValue{NewKeyValue("Error", errors.New("notation exists").Error())},
+			`{"Base":{"Func":0,"Data":null},"Error":"notation exists"}`,
+		},
+		{
+			&FSNCallParam{Func: GenNotationFunc, Data: []byte{0x01, 0x02}},
+			nil,
+			`{"Base":{"Func":0,"Data":"AQI="}}`,
+		},
+		{
+			sendAsset,
+			[]*KeyValue{NewKeyValue("AssetID", sendAsset.AssetID)},
+			`{"AssetID":"0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff","To":"0x0000000000000000000000000000000000000001","Value":5}`,
+		},
+		{
+			[]byte{0x01, 0x02},
+			[]*KeyValue{NewKeyValue("Error", "Ticket already exist")},
+			`{"Base":"AQI=","Error":"Ticket already exist"}`,
+		},
+	}
+	for i, test := range tests {
+		if have := string(FSNCallLogData(test.value, test.keyValues...)); have != test.want {
+			t.Errorf("test %d: log data mismatch:\nhave %s\nwant %s", i, have, test.want)
+		}
+	}
+}
@@ -84,8 +84,13 @@ func TestHexOrDecimal64(t *testing.T) {
 		// Invalid syntax:
 		{"abcdef", 0, false},
 		{"0xgg", 0, false},
+		// Boundary of 64 bits:
+		{"18446744073709551615", MaxUint64, true},
+		{"0xffffffffffffffff", MaxUint64, true},
 		// Doesn't fit into 64 bits:
+		{"18446744073709551616", 0, false},
 		{"18446744073709551617", 0, false},
+		{"0x10000000000000000", 0, false},
 	}
 	for _, test := range tests {
 		var num HexOrDecimal64
@@ -100,6 +105,38 @@ func TestHexOrDecimal64(t *testing.T) {
 	}
 }
 
+func TestParseUint64(t *testing.T) {
+	tests := []struct {
+		input string
+		num   uint64
+		ok    bool
+	}{
+		{"", 0, true},
+		{"0x", 0, false},
+		{"0X", 0, false},
+		{"0", 0, true},
+		{"0x0", 0, true},
+		{"12345", 12345, true},
+		{"0xff", 0xff, true},
+		{"18446744073709551615", MaxUint64, true},
+		{"0xffffffffffffffff", MaxUint64, true},
+		{"18446744073709551616", 0, false},
+		{"0x10000000000000000", 0, false},
+		{"-1", 0, false},
+		{"0xgg", 0, false},
+	}
+	for _, test := range tests {
+		num, ok := ParseUint64(test.input)
+		if ok != test.ok {
+			t.Errorf("ParseUint64(%q) -> ok = %t, want %t", test.input, ok, test.ok)
+			continue
+		}
+		if ok && num != test.num {
+			t.Errorf("ParseUint64(%q) -> %d, want %d", test.input, num, test.num)
+		}
+	}
+}
+
 func TestMustParseUint64(t *testing.T) {
 	if v := MustParseUint64("12345"); v != 12345 {
 		t.Errorf(`MustParseUint64("12345") = %d, want 12345`, v)
@@ -1,17 +1,79 @@
 package common
 
 import (
+	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math/big"
+	"sort"
+	"sync"
 
 	"github.com/FusionFoundation/efsn/log"
+	"github.com/FusionFoundation/efsn/rlp"
 )
 
-// TicketPrice  place holder for ticket price
+// TicketPriceEntry sets the ticket price from ActivationBlock onwards.
+type TicketPriceEntry struct {
+	ActivationBlock uint64
+	Price           *big.Int
+}
+
+var (
+	defaultTicketPrice = new(big.Int).Mul(big.NewInt(5000), big.NewInt(1000000000000000000))
+
+	ticketPriceMu       sync.RWMutex
+	ticketPriceSchedule []TicketPriceEntry
+)
+
+// SetTicketPriceSchedule replaces the ticket price schedule. Before the
+// first activation block the default price of 5000 FSN applies.
+//
+// The ticket price is consensus critical: the schedule must be identical on
+// all nodes and should only be set once at startup, before any block is
+// processed. An entry without a positive price or with a duplicate
+// activation block is rejected and leaves the current schedule in place.
+func SetTicketPriceSchedule(entries []TicketPriceEntry) error {
+	schedule := make([]TicketPriceEntry, len(entries))
+	for i, entry := range entries {
+		if entry.Price == nil || entry.Price.Sign() <= 0 {
+			return fmt.Errorf("invalid ticket price %v at block %d", entry.Price, entry.ActivationBlock)
+		}
+		schedule[i] = TicketPriceEntry{ActivationBlock: entry.ActivationBlock, Price: new(big.Int).Set(entry.Price)}
+	}
+	sort.Slice(schedule, func(i, j int) bool {
+		return schedule[i].ActivationBlock < schedule[j].ActivationBlock
+	})
+	for i := 1; i < len(schedule); i++ {
+		if schedule[i].ActivationBlock == schedule[i-1].ActivationBlock {
+			return fmt.Errorf("duplicate ticket price activation block %d", schedule[i].ActivationBlock)
+		}
+	}
+
+	ticketPriceMu.Lock()
+	ticketPriceSchedule = schedule
+	ticketPriceMu.Unlock()
+	return nil
+}
+
+// TicketPrice returns the ticket price at the given block number. A nil
+// block number yields the default price.
 func TicketPrice(blocknumber *big.Int) *big.Int {
-	oneFSN := big.NewInt(1000000000000000000)
-	return new(big.Int).Mul(big.NewInt(5000), oneFSN)
+	if blocknumber == nil {
+		return new(big.Int).Set(defaultTicketPrice)
+	}
+	ticketPriceMu.RLock()
+	defer ticketPriceMu.RUnlock()
+
+	price := defaultTicketPrice
+	for _, entry := range ticketPriceSchedule {
+		if blocknumber.Uint64() < entry.ActivationBlock {
+			break
+		}
+		price = entry.Price
+	}
+	return new(big.Int).Set(price)
 }
 
 // Ticket wacom
@@ -27,6 +89,8 @@ type TicketBodySlice []TicketBody
 type Ticket struct {
 	Owner Address
 	TicketBody
+
+	weight *big.Int // selection weight, not part of the consensus encoding
 }
 
 type TicketSlice []Ticket
@@ -51,6 +115,17 @@ func (t *TicketBody) IsInGenesis() bool {
 	return t.Height == 0
 }
 
+// IsExpired reports whether the ticket has expired at the given timestamp.
+func (t *TicketBody) IsExpired(timestamp uint64) bool {
+	return timestamp >= t.ExpireTime
+}
+
+// IsActive reports whether the ticket is usable at the given timestamp,
+// i.e. it has started and has not yet expired.
+func (t *TicketBody) IsActive(timestamp uint64) bool {
+	return timestamp >= t.StartTime && timestamp < t.ExpireTime
+}
+
 func (t *TicketBody) BlockHeight() *big.Int {
 	return new(big.Int).SetUint64(t.Height)
 }
@@ -59,24 +134,96 @@ func (t *TicketBody) Value() *big.Int {
 	return TicketPrice(new(big.Int).SetUint64(t.Height))
 }
 
+// Weight returns the selection weight of the ticket, or nil if not set.
+func (t *Ticket) Weight() *big.Int {
+	return t.weight
+}
+
+// SetWeight sets the selection weight of the ticket.
+func (t *Ticket) SetWeight(weight *big.Int) {
+	t.weight = weight
+}
+
+// ComputeWeight computes and sets the selection weight of the ticket as
+//
+//	Value * (ExpireTime - StartTime) * (currentBlock - Height + 1)
+//
+// so that a ticket's weight grows with its value, its lifetime and its age
+// in blocks. A ticket bought after currentBlock has no weight, and a nil
+// currentBlock counts the age as 1.
+//
+// This is an off-chain heuristic for use with SelectWeighted. It does not
+// reproduce the datong engine's ticket selection, which favours younger
+// tickets by distance rather than weighting by value and lifetime.
+func (t *Ticket) ComputeWeight(currentBlock *big.Int) *big.Int {
+	weight := new(big.Int)
+	if t.ExpireTime > t.StartTime {
+		age := big.NewInt(1)
+		if currentBlock != nil {
+			age.Sub(currentBlock, t.BlockHeight())
+			age.Add(age, Big1)
+		}
+		if age.Sign() > 0 {
+			weight.SetUint64(t.ExpireTime - t.StartTime)
+			weight.Mul(weight, t.Value())
+			weight.Mul(weight, age)
+		}
+	}
+	t.weight = weight
+	return weight
+}
+
+// TicketJSON is the JSON representation of a Ticket. Numbers are decimal
+// strings so that they survive JSON number precision limits.
+type TicketJSON struct {
+	ID         Hash
+	Owner      Address
+	Height     uint64 `json:",string"`
+	StartTime  uint64 `json:",string"`
+	ExpireTime uint64 `json:",string"`
+	Value      string
+	Weight     string
+}
+
 func (t *Ticket) MarshalJSON() ([]byte, error) {
-	return json.Marshal(&struct {
-		ID         Hash
-		Owner      Address
-		Height     uint64
-		StartTime  uint64
-		ExpireTime uint64
-		Value      string
-	}{
+	weight := "0"
+	if t.weight != nil {
+		weight = t.weight.String()
+	}
+	return json.Marshal(&TicketJSON{
 		ID:         t.ID,
 		Owner:      t.Owner,
 		Height:     t.Height,
 		StartTime:  t.StartTime,
 		ExpireTime: t.ExpireTime,
 		Value:      t.Value().String(),
+		Weight:     weight,
 	})
 }
 
+// UnmarshalJSON restores a ticket encoded by MarshalJSON. Value is derived
+// from Height and therefore ignored.
+func (t *Ticket) UnmarshalJSON(input []byte) error {
+	var dec TicketJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	t.ID = dec.ID
+	t.Owner = dec.Owner
+	t.Height = dec.Height
+	t.StartTime = dec.StartTime
+	t.ExpireTime = dec.ExpireTime
+	t.weight = nil
+	if dec.Weight != "" {
+		weight, ok := new(big.Int).SetString(dec.Weight, 10)
+		if !ok {
+			return fmt.Errorf("invalid ticket weight %q", dec.Weight)
+		}
+		t.weight = weight
+	}
+	return nil
+}
+
 func (t *Ticket) String() string {
 	b, _ := json.Marshal(t)
 	return string(b)
@@ -110,14 +257,348 @@ func (s TicketSlice) ToMap() map[Hash]TicketDisplay {
 	return r
 }
 
+func (s TicketSlice) Len() int {
+	return len(s)
+}
+
+func (s TicketSlice) Less(i, j int) bool {
+	return bytes.Compare(s[i].ID[:], s[j].ID[:]) < 0
+}
+
+func (s TicketSlice) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+// Sort sorts the tickets in place by ascending ID.
+func (s TicketSlice) Sort() {
+	sort.Sort(s)
+}
+
+// IsSorted reports whether the tickets are sorted by ascending ID.
+func (s TicketSlice) IsSorted() bool {
+	return sort.IsSorted(s)
+}
+
+// Get returns a pointer to the ticket with the given id. The pointer refers
+// to the slice's backing array, so it stays valid across further lookups.
+func (s TicketSlice) Get(id Hash) (*Ticket, bool) {
+	for i := range s {
+		if s[i].ID == id {
+			return &s[i], true
+		}
+	}
+	return nil, false
+}
+
+// FilterByOwner returns the tickets owned by owner, preserving their order.
+func (s TicketSlice) FilterByOwner(owner Address) TicketSlice {
+	res := make(TicketSlice, 0, s.CountByOwner(owner))
+	for _, t := range s {
+		if t.Owner == owner {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// ExpiredBefore returns the tickets expired at timestamp, i.e. with
+// ExpireTime <= timestamp, preserving their order.
+func (s TicketSlice) ExpiredBefore(timestamp uint64) TicketSlice {
+	return s.filterExpired(timestamp, true)
+}
+
+// RemoveExpired returns the tickets not expired at timestamp, preserving
+// their order. s is not modified.
+func (s TicketSlice) RemoveExpired(timestamp uint64) TicketSlice {
+	return s.filterExpired(timestamp, false)
+}
+
+func (s TicketSlice) filterExpired(timestamp uint64, expired bool) TicketSlice {
+	res := make(TicketSlice, 0, len(s))
+	for _, t := range s {
+		if t.IsExpired(timestamp) == expired {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// Shuffle permutes the tickets in place with a Fisher-Yates shuffle driven
+// by NewHashRand(seed). The same seed and slice give the same permutation.
+func (s TicketSlice) Shuffle(seed Hash) {
+	rnd := NewHashRand(seed)
+	for i := len(s) - 1; i > 0; i-- {
+		j := rnd.Intn(i + 1)
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+// rlpSize returns the length of the RLP encoding of the ticket.
+func (t *Ticket) rlpSize() uint64 {
+	body := HashLength + 1 + rlpUint64Size(t.Height) + rlpUint64Size(t.StartTime) + rlpUint64Size(t.ExpireTime)
+	return rlp.ListSize(AddressLength + 1 + rlp.ListSize(uint64(body)))
+}
+
+// EncodeRLP implements rlp.Encoder, writing the tickets to w one at a time
+// instead of building the whole encoding in memory. The output is the same
+// as the default encoding of a []Ticket.
+func (s TicketSlice) EncodeRLP(w io.Writer) error {
+	var size uint64
+	for i := range s {
+		size += s[i].rlpSize()
+	}
+	if _, err := w.Write(rlpListHeader(size)); err != nil {
+		return err
+	}
+	for i := range s {
+		if err := rlp.Encode(w, &s[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rlpListHeader returns the header of an RLP list with the given content
+// size.
+func rlpListHeader(size uint64) []byte {
+	if size < 56 {
+		return []byte{0xc0 + byte(size)}
+	}
+	var buf [9]byte
+	binary.BigEndian.PutUint64(buf[1:], size)
+	n := 1
+	for buf[n] == 0 {
+		n++
+	}
+	buf[n-1] = 0xf7 + byte(9-n)
+	return buf[n-1:]
+}
+
+// DecodeTicketSlice reads an RLP encoded ticket list from r, decoding the
+// tickets one at a time.
+func DecodeTicketSlice(r io.Reader) (TicketSlice, error) {
+	stream := rlp.NewStream(r, 0)
+	if _, err := stream.List(); err != nil {
+		return nil, err
+	}
+	var s TicketSlice
+	for {
+		var t Ticket
+		if err := stream.Decode(&t); err == rlp.EOL {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		s = append(s, t)
+	}
+	if err := stream.ListEnd(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GroupByOwner returns the tickets grouped by owner, keeping their order
+// within each group.
+func (s TicketSlice) GroupByOwner() map[Address]TicketSlice {
+	groups := make(map[Address]TicketSlice)
+	for _, t := range s {
+		groups[t.Owner] = append(groups[t.Owner], t)
+	}
+	return groups
+}
+
+// CountByOwner returns the number of tickets owned by owner.
+func (s TicketSlice) CountByOwner(owner Address) int {
+	count := 0
+	for _, t := range s {
+		if t.Owner == owner {
+			count++
+		}
+	}
+	return count
+}
+
+// TotalValue returns the summed value of all tickets.
+func (s TicketSlice) TotalValue() *big.Int {
+	total := new(big.Int)
+	for i := range s {
+		total.Add(total, s[i].Value())
+	}
+	return total
+}
+
+// TotalWeight returns the summed weight of all tickets. Tickets without a
+// weight are skipped.
+func (s TicketSlice) TotalWeight() *big.Int {
+	total := new(big.Int)
+	for i := range s {
+		if weight := s[i].Weight(); weight != nil {
+			total.Add(total, weight)
+		}
+	}
+	return total
+}
+
+// SelectWeighted deterministically picks a ticket with probability
+// proportional to its weight, using the Keccak256 hash of seed as the random
+// draw. Tickets without a weight are never selected.
+func (s TicketSlice) SelectWeighted(seed Hash) (*Ticket, error) {
+	total := s.TotalWeight()
+	if total.Sign() <= 0 {
+		return nil, fmt.Errorf("total ticket weight is zero")
+	}
+	draw := Keccak256Hash(seed[:]).Big()
+	draw.Mod(draw, total)
+	for i := range s {
+		weight := s[i].Weight()
+		if weight == nil || weight.Sign() <= 0 {
+			continue
+		}
+		if draw.Cmp(weight) < 0 {
+			return &s[i], nil
+		}
+		draw.Sub(draw, weight)
+	}
+	return nil, fmt.Errorf("weighted ticket selection failed")
+}
+
+// MerkleRoot returns the root of a binary Keccak256 merkle tree over the
+// RLP hashes of the tickets sorted by ID, so it does not depend on their
+// order in s. A node without a sibling is carried up to the next level
+// unchanged. The root of an empty slice is the zero hash.
+func (s TicketSlice) MerkleRoot() Hash {
+	if len(s) == 0 {
+		return Hash{}
+	}
+	sorted := s.DeepCopy()
+	sorted.Sort()
+	level := make([]Hash, len(sorted))
+	for i := range sorted {
+		level[i] = rlpHash(&sorted[i])
+	}
+	for len(level) > 1 {
+		next := level[:0]
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+			} else {
+				next = append(next, Keccak256Hash(level[i][:], level[i+1][:]))
+			}
+		}
+		level = next
+	}
+	return level[0]
+}
+
 func (s TicketSlice) DeepCopy() TicketSlice {
 	r := make(TicketSlice, len(s))
 	for i, t := range s {
+		t.weight = copyBig(t.weight)
 		r[i] = t
 	}
 	return r
 }
 
+// TicketMap indexes tickets by ID for constant time lookups.
+type TicketMap map[Hash]Ticket
+
+// TicketMapFromSlice builds a TicketMap holding all tickets of s.
+func TicketMapFromSlice(s TicketSlice) TicketMap {
+	m := make(TicketMap, len(s))
+	for _, t := range s {
+		m[t.ID] = t
+	}
+	return m
+}
+
+// Get returns a copy of the ticket with the given id.
+func (m TicketMap) Get(id Hash) (Ticket, bool) {
+	t, ok := m[id]
+	return t, ok
+}
+
+// Add inserts ticket into the map, failing if its ID is already present.
+func (m TicketMap) Add(ticket Ticket) error {
+	if _, exist := m[ticket.ID]; exist {
+		return fmt.Errorf("AddTicket: %v ticket exist", ticket.ID.String())
+	}
+	m[ticket.ID] = ticket
+	return nil
+}
+
+// Delete removes the ticket with the given id.
+func (m TicketMap) Delete(id Hash) error {
+	if _, exist := m[id]; !exist {
+		return fmt.Errorf("RemoveTicket: %v ticket not found", id.String())
+	}
+	delete(m, id)
+	return nil
+}
+
+// SyncTicketMap is a TicketMap safe for concurrent use. The zero value is
+// an empty map ready to use.
+type SyncTicketMap struct {
+	mu sync.RWMutex
+	m  TicketMap
+}
+
+// NewSyncTicketMap returns a SyncTicketMap holding all tickets of s.
+func NewSyncTicketMap(s TicketSlice) *SyncTicketMap {
+	return &SyncTicketMap{m: TicketMapFromSlice(s)}
+}
+
+// Get returns a copy of the ticket with the given id.
+func (m *SyncTicketMap) Get(id Hash) (Ticket, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := m.m[id]
+	t.weight = copyBig(t.weight)
+	return t, ok
+}
+
+// Set stores ticket, replacing any ticket with the same ID.
+func (m *SyncTicketMap) Set(ticket Ticket) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.m == nil {
+		m.m = make(TicketMap)
+	}
+	ticket.weight = copyBig(ticket.weight)
+	m.m[ticket.ID] = ticket
+}
+
+// Delete removes the ticket with the given id.
+func (m *SyncTicketMap) Delete(id Hash) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.m, id)
+}
+
+// Len returns the number of tickets.
+func (m *SyncTicketMap) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.m)
+}
+
+// Range calls f with a copy of each ticket until f returns false. The map
+// is read locked for the duration, so f must not modify it.
+func (m *SyncTicketMap) Range(f func(Hash, Ticket) bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for id, t := range m.m {
+		t.weight = copyBig(t.weight)
+		if !f(id, t) {
+			return
+		}
+	}
+}
+
 func (s TicketBodySlice) DeepCopy() TicketBodySlice {
 	res := make(TicketBodySlice, len(s))
 	for i, v := range s {
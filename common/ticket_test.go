@@ -0,0 +1,666 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/FusionFoundation/efsn/rlp"
+)
+
+func newTestTicketSlice(n int, seed int64) TicketSlice {
+	r := rand.New(rand.NewSource(seed))
+	s := make(TicketSlice, n)
+	for i := range s {
+		r.Read(s[i].ID[:])
+		r.Read(s[i].Owner[:])
+		s[i].Height = uint64(i)
+	}
+	return s
+}
+
+func TestTicketSliceSort(t *testing.T) {
+	s := newTestTicketSlice(100, 1)
+	if s.IsSorted() {
+		t.Fatal("random ticket slice unexpectedly sorted")
+	}
+	s.Sort()
+	if !s.IsSorted() {
+		t.Fatal("ticket slice not sorted after Sort")
+	}
+	for i := 1; i < len(s); i++ {
+		if s[i-1].ID.Big().Cmp(s[i].ID.Big()) > 0 {
+			t.Fatalf("ticket %d (%x) sorted before ticket %d (%x)", i-1, s[i-1].ID, i, s[i].ID)
+		}
+	}
+}
+
+func BenchmarkTicketSliceSort(b *testing.B) {
+	orig := newTestTicketSlice(1000, 1)
+	s := make(TicketSlice, len(orig))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		copy(s, orig)
+		s.Sort()
+	}
+}
+
+func TestTicketSliceGet(t *testing.T) {
+	s := newTestTicketSlice(3, 1)
+	a, ok := s.Get(s[0].ID)
+	if !ok {
+		t.Fatalf("ticket %x not found", s[0].ID)
+	}
+	b, ok := s.Get(s[2].ID)
+	if !ok {
+		t.Fatalf("ticket %x not found", s[2].ID)
+	}
+	if a == b {
+		t.Fatal("Get returned the same pointer for different tickets")
+	}
+	if a.ID != s[0].ID || a.Owner != s[0].Owner || a.Height != 0 {
+		t.Errorf("first ticket mismatch: have %v, want %v", a, &s[0])
+	}
+	if b.ID != s[2].ID || b.Owner != s[2].Owner || b.Height != 2 {
+		t.Errorf("second ticket mismatch: have %v, want %v", b, &s[2])
+	}
+	if _, ok := s.Get(Hash{}); ok {
+		t.Error("Get found a ticket for an unknown id")
+	}
+}
+
+func TestTicketActiveExpired(t *testing.T) {
+	ticket := Ticket{TicketBody: TicketBody{StartTime: 100, ExpireTime: 200}}
+	tests := []struct {
+		timestamp uint64
+		active    bool
+		expired   bool
+	}{
+		{0, false, false},
+		{99, false, false},
+		{100, true, false},
+		{101, true, false},
+		{199, true, false},
+		{200, false, true},
+		{201, false, true},
+	}
+	for _, test := range tests {
+		if active := ticket.IsActive(test.timestamp); active != test.active {
+			t.Errorf("IsActive(%d) = %v, want %v", test.timestamp, active, test.active)
+		}
+		if expired := ticket.IsExpired(test.timestamp); expired != test.expired {
+			t.Errorf("IsExpired(%d) = %v, want %v", test.timestamp, expired, test.expired)
+		}
+	}
+}
+
+func TestTicketSliceExpiredBefore(t *testing.T) {
+	expires := []uint64{100, 200, 150, 99, 300, 101}
+	s := newTestTicketSlice(len(expires), 1)
+	for i := range s {
+		s[i].ExpireTime = expires[i]
+	}
+	expired, active := s.ExpiredBefore(100), s.RemoveExpired(100)
+	if len(expired) != 2 || expired[0].ID != s[0].ID || expired[1].ID != s[3].ID {
+		t.Errorf("ExpiredBefore(100) = %v", expired)
+	}
+	if len(expired)+len(active) != len(s) {
+		t.Fatalf("results have %d+%d tickets, want %d", len(expired), len(active), len(s))
+	}
+	for _, ticket := range active {
+		if ticket.ExpireTime <= 100 {
+			t.Errorf("expired ticket %x kept", ticket.ID)
+		}
+		if _, found := expired.Get(ticket.ID); found {
+			t.Errorf("ticket %x in both results", ticket.ID)
+		}
+	}
+	if len(s.ExpiredBefore(0)) != 0 || len(s.RemoveExpired(300)) != 0 {
+		t.Error("unexpected tickets at the boundaries")
+	}
+}
+
+func TestTicketSliceFilterByOwner(t *testing.T) {
+	alice := HexToAddress("0x01")
+	bob := HexToAddress("0x02")
+	owners := []Address{alice, {}, bob, alice, {}, alice}
+	s := make(TicketSlice, len(owners))
+	for i, owner := range owners {
+		s[i].Owner = owner
+		s[i].ID = BigToHash(big.NewInt(int64(i + 1)))
+	}
+	tests := []struct {
+		owner Address
+		ids   []int64
+	}{
+		{alice, []int64{1, 4, 6}},
+		{bob, []int64{3}},
+		{Address{}, []int64{2, 5}},
+		{HexToAddress("0x03"), nil},
+	}
+	for _, test := range tests {
+		if count := s.CountByOwner(test.owner); count != len(test.ids) {
+			t.Errorf("CountByOwner(%x) = %d, want %d", test.owner, count, len(test.ids))
+		}
+		res := s.FilterByOwner(test.owner)
+		if len(res) != len(test.ids) {
+			t.Errorf("FilterByOwner(%x) returned %d tickets, want %d", test.owner, len(res), len(test.ids))
+			continue
+		}
+		for i, id := range test.ids {
+			if res[i].ID != BigToHash(big.NewInt(id)) || res[i].Owner != test.owner {
+				t.Errorf("FilterByOwner(%x)[%d] = %v, want id %d", test.owner, i, &res[i], id)
+			}
+		}
+	}
+}
+
+func TestTicketJSONRoundTrip(t *testing.T) {
+	for _, weight := range []*big.Int{nil, big.NewInt(12345)} {
+		ticket := Ticket{
+			Owner: HexToAddress("0x01"),
+			TicketBody: TicketBody{
+				ID:         HexToHash("0x02"),
+				Height:     3,
+				StartTime:  4,
+				ExpireTime: 5,
+			},
+		}
+		ticket.SetWeight(weight)
+		enc, err := json.Marshal(&ticket)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var dec Ticket
+		if err := json.Unmarshal(enc, &dec); err != nil {
+			t.Fatalf("weight %v: unmarshal error: %v", weight, err)
+		}
+		if dec.Owner != ticket.Owner || dec.TicketBody != ticket.TicketBody {
+			t.Errorf("weight %v: ticket mismatch: have %v, want %v", weight, &dec, &ticket)
+		}
+		want := weight
+		if want == nil {
+			want = Big0
+		}
+		if dec.Weight() == nil || dec.Weight().Cmp(want) != 0 {
+			t.Errorf("weight mismatch: have %v, want %v", dec.Weight(), want)
+		}
+	}
+}
+
+func TestTicketJSONLargeTimes(t *testing.T) {
+	ticket := Ticket{
+		Owner: HexToAddress("0x01"),
+		TicketBody: TicketBody{
+			ID:         HexToHash("0x02"),
+			Height:     1<<53 + 1,
+			StartTime:  1<<63 + 1,
+			ExpireTime: math.MaxUint64,
+		},
+	}
+	enc, err := json.Marshal(&ticket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var shape TicketJSON
+	if err := json.Unmarshal(enc, &shape); err != nil {
+		t.Fatalf("unmarshal into TicketJSON: %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(enc, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Height", "StartTime", "ExpireTime"} {
+		if _, ok := fields[name].(string); !ok {
+			t.Errorf("%s encoded as %T, want string", name, fields[name])
+		}
+	}
+	if fields["ExpireTime"] != "18446744073709551615" {
+		t.Errorf("ExpireTime = %v", fields["ExpireTime"])
+	}
+
+	var dec Ticket
+	if err := json.Unmarshal(enc, &dec); err != nil {
+		t.Fatal(err)
+	}
+	if dec.TicketBody != ticket.TicketBody {
+		t.Errorf("ticket mismatch: have %v, want %v", &dec, &ticket)
+	}
+}
+
+func TestTicketSliceMerkleRoot(t *testing.T) {
+	if root := (TicketSlice{}).MerkleRoot(); root != (Hash{}) {
+		t.Errorf("empty root = %x, want zero hash", root)
+	}
+	single := newTestTicketSlice(1, 1)
+	if root := single.MerkleRoot(); root != rlpHash(&single[0]) {
+		t.Errorf("single ticket root = %x, want its hash %x", root, rlpHash(&single[0]))
+	}
+
+	for _, n := range []int{2, 3, 7, 8} {
+		s := newTestTicketSlice(n, 1)
+		root := s.MerkleRoot()
+		for seed := byte(0); seed < 5; seed++ {
+			perm := s.DeepCopy()
+			perm.Shuffle(Hash{seed})
+			if have := perm.MerkleRoot(); have != root {
+				t.Errorf("n=%d: permuted root %x, want %x", n, have, root)
+			}
+		}
+		if !reflect.DeepEqual(s, newTestTicketSlice(n, 1)) {
+			t.Errorf("n=%d: MerkleRoot modified the slice", n)
+		}
+
+		changed := s.DeepCopy()
+		changed[n-1].ExpireTime++
+		if changed.MerkleRoot() == root {
+			t.Errorf("n=%d: changing a ticket did not change the root", n)
+		}
+		if s[:n-1].MerkleRoot() == root {
+			t.Errorf("n=%d: removing a ticket did not change the root", n)
+		}
+	}
+}
+
+func TestTicketSliceDeepCopy(t *testing.T) {
+	s := newTestTicketSlice(2, 1)
+	s[0].SetWeight(big.NewInt(10))
+	c := s.DeepCopy()
+	if !reflect.DeepEqual(c, s) {
+		t.Fatalf("copy mismatch: have %v, want %v", c, s)
+	}
+	c[0].Weight().SetInt64(20)
+	c[1].ComputeWeight(nil)
+	if s[0].Weight().Int64() != 10 || s[1].Weight() != nil {
+		t.Error("original modified through copy")
+	}
+}
+
+func TestTicketMap(t *testing.T) {
+	s := newTestTicketSlice(10, 1)
+	m := TicketMapFromSlice(s)
+	if len(m) != len(s) {
+		t.Fatalf("map has %d tickets, want %d", len(m), len(s))
+	}
+	for _, ticket := range s {
+		got, ok := m.Get(ticket.ID)
+		if !ok || got.ID != ticket.ID || got.Owner != ticket.Owner {
+			t.Errorf("Get(%x) = %v, %v", ticket.ID, &got, ok)
+		}
+	}
+
+	// Get returns a copy
+	got, _ := m.Get(s[0].ID)
+	got.Height = 100
+	if stored, _ := m.Get(s[0].ID); stored.Height != s[0].Height {
+		t.Error("modifying the result of Get changed the stored ticket")
+	}
+
+	if err := m.Add(s[0]); err == nil {
+		t.Error("expected error adding an existing ticket")
+	}
+	if err := m.Delete(s[0].ID); err != nil {
+		t.Errorf("unexpected error deleting ticket: %v", err)
+	}
+	if _, ok := m.Get(s[0].ID); ok {
+		t.Error("deleted ticket still present")
+	}
+	if err := m.Delete(s[0].ID); err == nil {
+		t.Error("expected error deleting a missing ticket")
+	}
+	if err := m.Add(s[0]); err != nil {
+		t.Errorf("unexpected error re-adding ticket: %v", err)
+	}
+	if len(m) != len(s) {
+		t.Errorf("map has %d tickets, want %d", len(m), len(s))
+	}
+}
+
+func TestSyncTicketMap(t *testing.T) {
+	s := newTestTicketSlice(100, 1)
+	var m SyncTicketMap
+	for _, ticket := range s[:50] {
+		m.Set(ticket)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for _, ticket := range s[50+i:] {
+				m.Set(ticket)
+				if i%2 == 1 {
+					m.Delete(ticket.ID)
+				}
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for _, ticket := range s {
+				if got, ok := m.Get(ticket.ID); ok && got.ID != ticket.ID {
+					t.Errorf("Get(%x) returned ticket %x", ticket.ID, got.ID)
+				}
+				m.Range(func(id Hash, got Ticket) bool {
+					return id == got.ID
+				})
+				m.Len()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, ticket := range s[:50] {
+		if _, ok := m.Get(ticket.ID); !ok {
+			t.Errorf("ticket %x missing", ticket.ID)
+		}
+	}
+
+	// Get returns a copy
+	got, _ := m.Get(s[0].ID)
+	got.SetWeight(big.NewInt(1))
+	got.Height = 100
+	if stored, _ := m.Get(s[0].ID); stored.Height != s[0].Height || stored.Weight() != nil {
+		t.Error("modifying the result of Get changed the stored ticket")
+	}
+
+	// Range stops when f returns false
+	var calls int
+	m.Range(func(Hash, Ticket) bool {
+		calls++
+		return false
+	})
+	if calls != 1 {
+		t.Errorf("Range called f %d times after it returned false", calls)
+	}
+
+	n := NewSyncTicketMap(s)
+	if n.Len() != len(s) {
+		t.Errorf("Len() = %d, want %d", n.Len(), len(s))
+	}
+	n.Delete(s[0].ID)
+	if _, ok := n.Get(s[0].ID); ok || n.Len() != len(s)-1 {
+		t.Error("ticket not deleted")
+	}
+}
+
+func BenchmarkTicketSliceGet(b *testing.B) {
+	s := newTestTicketSlice(1000, 1)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i := range s {
+			s.Get(s[i].ID)
+		}
+	}
+}
+
+func BenchmarkTicketMapGet(b *testing.B) {
+	s := newTestTicketSlice(1000, 1)
+	m := TicketMapFromSlice(s)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i := range s {
+			m.Get(s[i].ID)
+		}
+	}
+}
+
+func TestTicketSliceTotals(t *testing.T) {
+	var empty TicketSlice
+	if v := empty.TotalValue(); v == nil || v.Sign() != 0 {
+		t.Errorf("empty TotalValue = %v, want 0", v)
+	}
+	if w := empty.TotalWeight(); w == nil || w.Sign() != 0 {
+		t.Errorf("empty TotalWeight = %v, want 0", w)
+	}
+
+	s := newTestTicketSlice(4, 1)
+	s[0].SetWeight(big.NewInt(3))
+	s[2].SetWeight(big.NewInt(5))
+	if w := s.TotalWeight(); w.Int64() != 8 {
+		t.Errorf("TotalWeight with nil weights = %v, want 8", w)
+	}
+	s[1].SetWeight(big.NewInt(7))
+	s[3].SetWeight(big.NewInt(11))
+	if w := s.TotalWeight(); w.Int64() != 26 {
+		t.Errorf("TotalWeight = %v, want 26", w)
+	}
+	want := new(big.Int).Mul(TicketPrice(Big0), big.NewInt(4))
+	if v := s.TotalValue(); v.Cmp(want) != 0 {
+		t.Errorf("TotalValue = %v, want %v", v, want)
+	}
+}
+
+func TestTicketPriceSchedule(t *testing.T) {
+	defer SetTicketPriceSchedule(nil)
+
+	oneFSN := big.NewInt(1000000000000000000)
+	fsn := func(n int64) *big.Int { return new(big.Int).Mul(big.NewInt(n), oneFSN) }
+
+	if price := TicketPrice(big.NewInt(1000000)); price.Cmp(fsn(5000)) != 0 {
+		t.Fatalf("default price = %v, want %v", price, fsn(5000))
+	}
+	if err := SetTicketPriceSchedule([]TicketPriceEntry{
+		{ActivationBlock: 2000, Price: fsn(7000)},
+		{ActivationBlock: 1000, Price: fsn(6000)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		block *big.Int
+		price *big.Int
+	}{
+		{big.NewInt(0), fsn(5000)},
+		{big.NewInt(999), fsn(5000)},
+		{big.NewInt(1000), fsn(6000)},
+		{big.NewInt(1999), fsn(6000)},
+		{big.NewInt(2000), fsn(7000)},
+		{big.NewInt(5000), fsn(7000)},
+		{nil, fsn(5000)},
+	}
+	for _, test := range tests {
+		if price := TicketPrice(test.block); price.Cmp(test.price) != 0 {
+			t.Errorf("TicketPrice(%v) = %v, want %v", test.block, price, test.price)
+		}
+	}
+
+	// invalid schedules are rejected and keep the current one
+	invalid := [][]TicketPriceEntry{
+		{{ActivationBlock: 3000, Price: nil}},
+		{{ActivationBlock: 3000, Price: big.NewInt(0)}},
+		{{ActivationBlock: 3000, Price: big.NewInt(-1)}},
+		{{ActivationBlock: 3000, Price: fsn(8000)}, {ActivationBlock: 3000, Price: fsn(9000)}},
+	}
+	for i, entries := range invalid {
+		if err := SetTicketPriceSchedule(entries); err == nil {
+			t.Errorf("invalid schedule %d accepted", i)
+		}
+	}
+	if price := TicketPrice(big.NewInt(5000)); price.Cmp(fsn(7000)) != 0 {
+		t.Errorf("schedule replaced by invalid one: price %v", price)
+	}
+
+	// the returned price may be modified by the caller
+	TicketPrice(big.NewInt(1000)).SetInt64(1)
+	if price := TicketPrice(big.NewInt(1000)); price.Cmp(fsn(6000)) != 0 {
+		t.Errorf("schedule modified through returned price: %v", price)
+	}
+
+	// the schedule may be replaced while prices are read
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			SetTicketPriceSchedule([]TicketPriceEntry{{ActivationBlock: 1000, Price: fsn(6000)}})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			TicketPrice(big.NewInt(1000))
+		}
+	}()
+	wg.Wait()
+}
+
+func TestTicketSliceSelectWeighted(t *testing.T) {
+	s := newTestTicketSlice(4, 1)
+	if _, err := s.SelectWeighted(Hash{}); err == nil {
+		t.Fatal("expected error for zero total weight")
+	}
+	weights := []int64{1, 0, 3, 6}
+	for i, w := range weights {
+		s[i].SetWeight(big.NewInt(w))
+	}
+
+	seed := HexToHash("0x1234")
+	first, err := s.SelectWeighted(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if ticket, _ := s.SelectWeighted(seed); ticket != first {
+			t.Fatalf("selection not deterministic: %x != %x", ticket.ID, first.ID)
+		}
+	}
+
+	const rounds = 10000
+	counts := make(map[Hash]int)
+	for i := 0; i < rounds; i++ {
+		ticket, err := s.SelectWeighted(BigToHash(big.NewInt(int64(i))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[ticket.ID]++
+	}
+	for i, w := range weights {
+		freq := float64(counts[s[i].ID]) / rounds
+		want := float64(w) / 10
+		if freq < want-0.03 || freq > want+0.03 {
+			t.Errorf("ticket %d (weight %d) selected with frequency %.3f, want %.3f", i, w, freq, want)
+		}
+	}
+}
+
+func TestTicketComputeWeight(t *testing.T) {
+	price := TicketPrice(nil)
+	newTicket := func(height, start, expire uint64) *Ticket {
+		return &Ticket{TicketBody: TicketBody{Height: height, StartTime: start, ExpireTime: expire}}
+	}
+	tests := []struct {
+		ticket *Ticket
+		block  *big.Int
+		want   *big.Int
+	}{
+		{newTicket(10, 0, 100), big.NewInt(10), new(big.Int).Mul(price, big.NewInt(100))},
+		{newTicket(10, 0, 100), big.NewInt(14), new(big.Int).Mul(price, big.NewInt(500))},
+		{newTicket(10, 0, 100), nil, new(big.Int).Mul(price, big.NewInt(100))},
+		{newTicket(10, 0, 100), big.NewInt(9), new(big.Int)},
+		{newTicket(10, 100, 100), big.NewInt(10), new(big.Int)},
+	}
+	for i, test := range tests {
+		weight := test.ticket.ComputeWeight(test.block)
+		if weight.Cmp(test.want) != 0 {
+			t.Errorf("test %d: weight = %v, want %v", i, weight, test.want)
+		}
+		if test.ticket.Weight().Cmp(test.want) != 0 {
+			t.Errorf("test %d: stored weight = %v, want %v", i, test.ticket.Weight(), test.want)
+		}
+	}
+
+	short, long := newTicket(10, 0, MinTicketLifetime), newTicket(10, 0, MaxTicketLifetime)
+	if short.ComputeWeight(big.NewInt(20)).Cmp(long.ComputeWeight(big.NewInt(20))) >= 0 {
+		t.Error("longer lived ticket does not weigh more")
+	}
+}
+
+func TestTicketSliceGroupByOwner(t *testing.T) {
+	alice, bob, carol := HexToAddress("0x01"), HexToAddress("0x02"), HexToAddress("0x03")
+	owners := []Address{alice, bob, alice, carol, bob, alice}
+	s := make(TicketSlice, len(owners))
+	for i, owner := range owners {
+		s[i].ID = BigToHash(big.NewInt(int64(i)))
+		s[i].Owner = owner
+	}
+	groups := s.GroupByOwner()
+	if len(groups) != 3 {
+		t.Fatalf("have %d groups, want 3", len(groups))
+	}
+	for _, owner := range []Address{alice, bob, carol} {
+		want := s.FilterByOwner(owner)
+		if !reflect.DeepEqual(groups[owner], want) {
+			t.Errorf("owner %x: have %v, want %v", owner, groups[owner], want)
+		}
+	}
+	if len(groups[carol]) != 1 {
+		t.Errorf("carol has %d tickets, want 1", len(groups[carol]))
+	}
+}
+
+func TestTicketSliceShuffle(t *testing.T) {
+	orig := newTestTicketSlice(50, 1)
+	seed := HexToHash("0x01")
+
+	s1 := append(TicketSlice{}, orig...)
+	s2 := append(TicketSlice{}, orig...)
+	s1.Shuffle(seed)
+	s2.Shuffle(seed)
+	if !reflect.DeepEqual(s1, s2) {
+		t.Error("same seed produced different permutations")
+	}
+	if reflect.DeepEqual(s1, orig) {
+		t.Error("shuffle left the slice unchanged")
+	}
+	s1.Sort()
+	sorted := append(TicketSlice{}, orig...)
+	sorted.Sort()
+	if !reflect.DeepEqual(s1, sorted) {
+		t.Error("shuffled slice does not hold the original tickets")
+	}
+
+	s3 := append(TicketSlice{}, orig...)
+	s3.Shuffle(HexToHash("0x02"))
+	if reflect.DeepEqual(s2, s3) {
+		t.Error("different seeds produced the same permutation")
+	}
+	TicketSlice(nil).Shuffle(seed)
+}
+
+func TestTicketSliceRLPStream(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 5000} {
+		s := newTestTicketSlice(n, 1)
+		r := rand.New(rand.NewSource(2))
+		for i := range s {
+			s[i].StartTime = r.Uint64() >> uint(r.Intn(64))
+			s[i].ExpireTime = r.Uint64()
+		}
+		var buf bytes.Buffer
+		if err := s.EncodeRLP(&buf); err != nil {
+			t.Fatal(err)
+		}
+		want, err := rlp.EncodeToBytes([]Ticket(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Fatalf("%d tickets: streamed encoding differs from default encoding", n)
+		}
+		dec, err := DecodeTicketSlice(&buf)
+		if err != nil {
+			t.Fatalf("%d tickets: decode error: %v", n, err)
+		}
+		if len(dec) != len(s) || (n > 0 && !reflect.DeepEqual(dec, s)) {
+			t.Errorf("%d tickets: round trip mismatch", n)
+		}
+	}
+	if _, err := DecodeTicketSlice(bytes.NewReader([]byte{0x80})); err == nil {
+		t.Error("expected error decoding a non-list")
+	}
+}
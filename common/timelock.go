@@ -20,6 +20,26 @@ const (
 	SmartTransfer
 )
 
+// IsValid reports whether t is one of the defined time lock types.
+func (t TimeLockType) IsValid() bool {
+	return t <= SmartTransfer
+}
+
+// String implements fmt.Stringer.
+func (t TimeLockType) String() string {
+	switch t {
+	case AssetToTimeLock:
+		return "AssetToTimeLock"
+	case TimeLockToTimeLock:
+		return "TimeLockToTimeLock"
+	case TimeLockToAsset:
+		return "TimeLockToAsset"
+	case SmartTransfer:
+		return "SmartTransfer"
+	}
+	return fmt.Sprintf("TimeLockType(%d)", uint(t))
+}
+
 const (
 	// TimeLockNow wacom
 	TimeLockNow uint64 = 0
@@ -31,7 +51,7 @@ const (
 type TimeLockItem struct {
 	StartTime uint64
 	EndTime   uint64
-	Value     *big.Int `json:",string"`
+	Value     *big.Int
 }
 
 func (z *TimeLockItem) IsValid() error {
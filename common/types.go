@@ -17,13 +17,18 @@
 package common
 
 import (
+	"bytes"
+	"crypto/subtle"
 	"database/sql/driver"
+	"encoding"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math/big"
 	"math/rand"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/FusionFoundation/efsn/common/hexutil"
@@ -71,6 +76,31 @@ func (h Hash) Big() *big.Int { return new(big.Int).SetBytes(h[:]) }
 // Hex converts a hash to a hex string.
 func (h Hash) Hex() string { return hexutil.Encode(h[:]) }
 
+// IsZero reports whether h is the zero hash.
+func (h Hash) IsZero() bool { return h == Hash{} }
+
+// Cmp compares h and other as big-endian numbers and returns -1, 0 or +1.
+func (h Hash) Cmp(other Hash) int {
+	return bytes.Compare(h[:], other[:])
+}
+
+// Equal reports whether h and other are identical, in constant time. Use it
+// instead of == when either side is derived from secret material and the
+// comparison must not leak how many leading bytes matched.
+func (h Hash) Equal(other Hash) bool {
+	return subtle.ConstantTimeCompare(h[:], other[:]) == 1
+}
+
+// HasPrefix reports whether h begins with p.
+func (h Hash) HasPrefix(p []byte) bool {
+	return bytes.HasPrefix(h[:], p)
+}
+
+// HasSuffix reports whether h ends with sfx.
+func (h Hash) HasSuffix(sfx []byte) bool {
+	return bytes.HasSuffix(h[:], sfx)
+}
+
 // TerminalString implements log.TerminalStringer, formatting a string for console
 // output during logging.
 func (h Hash) TerminalString() string {
@@ -104,6 +134,12 @@ func (h Hash) MarshalText() ([]byte, error) {
 	return hexutil.Bytes(h[:]).MarshalText()
 }
 
+// AppendHex appends the 0x prefixed hex encoding of h, as produced by
+// MarshalText, to dst. It does not allocate if dst has enough capacity.
+func (h Hash) AppendHex(dst []byte) []byte {
+	return appendHex(dst, h[:])
+}
+
 // SetBytes sets the hash to the value of b.
 // If b is larger than len(h), b will be cropped from the left.
 func (h *Hash) SetBytes(b []byte) {
@@ -114,6 +150,19 @@ func (h *Hash) SetBytes(b []byte) {
 	copy(h[HashLength-len(b):], b)
 }
 
+// SetString sets the hash from its hex representation. Unlike HexToHash it
+// requires exactly 64 hex digits, with an optional 0x prefix.
+func (h *Hash) SetString(s string) error {
+	if hasHexPrefix(s) {
+		s = s[2:]
+	}
+	if len(s) != 2*HashLength || !isHex(s) {
+		return fmt.Errorf("invalid hash %q, want %d hex digits", s, 2*HashLength)
+	}
+	h.SetBytes(Hex2Bytes(s))
+	return nil
+}
+
 // Generate implements testing/quick.Generator.
 func (h Hash) Generate(rand *rand.Rand, size int) reflect.Value {
 	m := rand.Intn(len(h))
@@ -123,6 +172,33 @@ func (h Hash) Generate(rand *rand.Rand, size int) reflect.Value {
 	return reflect.ValueOf(h)
 }
 
+// DedupHashes returns a new slice holding the hashes of in without
+// duplicates, in order of first occurrence.
+func DedupHashes(in []Hash) []Hash {
+	seen := make(map[Hash]struct{}, len(in))
+	out := make([]Hash, 0, len(in))
+	for _, h := range in {
+		if _, ok := seen[h]; !ok {
+			seen[h] = struct{}{}
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// Array returns the hash as a plain byte array.
+func (h Hash) Array() [HashLength]byte { return h }
+
+// HashFromArray returns the hash with the bytes of b.
+func HashFromArray(b [HashLength]byte) Hash { return b }
+
+// NewHashRand returns a math/rand generator seeded from the low 64 bits of
+// seed, giving reproducible sequences for tests and simulations. It is not
+// suitable for anything security or consensus related.
+func NewHashRand(seed Hash) *rand.Rand {
+	return rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(seed[HashLength-8:]))))
+}
+
 // Scan implements Scanner for database/sql.
 func (h *Hash) Scan(src interface{}) error {
 	srcB, ok := src.([]byte)
@@ -154,6 +230,16 @@ func (h UnprefixedHash) MarshalText() ([]byte, error) {
 	return []byte(hex.EncodeToString(h[:])), nil
 }
 
+// UnmarshalJSON parses a hash from a JSON string. The 0x prefix is optional.
+func (h *UnprefixedHash) UnmarshalJSON(input []byte) error {
+	return unmarshalUnprefixedJSON("UnprefixedHash", input, h)
+}
+
+// MarshalJSON encodes the hash as a JSON string of hex without 0x prefix.
+func (h UnprefixedHash) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + hex.EncodeToString(h[:]) + `"`), nil
+}
+
 /////////// Address
 
 // Address represents the 20 byte address of an Ethereum account.
@@ -175,6 +261,98 @@ func BigToAddress(b *big.Int) Address { return BytesToAddress(b.Bytes()) }
 // If s is larger than len(h), s will be cropped from the left.
 func HexToAddress(s string) Address { return BytesToAddress(FromHex(s)) }
 
+// HexToAddresses converts a list of hex strings to addresses. Unlike
+// HexToAddress it requires every entry to be a valid hex address.
+func HexToAddresses(ss []string) ([]Address, error) {
+	addrs := make([]Address, len(ss))
+	for i, s := range ss {
+		if !IsHexAddress(s) {
+			return nil, fmt.Errorf("invalid address %q at index %d", s, i)
+		}
+		addrs[i] = HexToAddress(s)
+	}
+	return addrs, nil
+}
+
+// PubkeyBytesToAddress derives the address of an uncompressed secp256k1
+// public key, given as the 64 byte X||Y coordinates with or without the
+// leading 0x04 marker.
+func PubkeyBytesToAddress(pub []byte) (Address, error) {
+	if len(pub) == 65 && pub[0] == 4 {
+		pub = pub[1:]
+	}
+	if len(pub) != 64 {
+		return Address{}, fmt.Errorf("invalid public key length %d", len(pub))
+	}
+	return BytesToAddress(Keccak256Hash(pub).Bytes()[12:]), nil
+}
+
+// AddressesToHex converts a list of addresses to their EIP55 hex strings.
+func AddressesToHex(addrs []Address) []string {
+	ss := make([]string, len(addrs))
+	for i, a := range addrs {
+		ss[i] = a.Hex()
+	}
+	return ss
+}
+
+// DedupAddresses returns a new slice holding the addresses of in without
+// duplicates, in order of first occurrence.
+func DedupAddresses(in []Address) []Address {
+	seen := make(map[Address]struct{}, len(in))
+	out := make([]Address, 0, len(in))
+	for _, a := range in {
+		if _, ok := seen[a]; !ok {
+			seen[a] = struct{}{}
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Array returns the address as a plain byte array.
+func (a Address) Array() [AddressLength]byte { return a }
+
+// AddressFromArray returns the address with the bytes of b.
+func AddressFromArray(b [AddressLength]byte) Address { return b }
+
+// AddressSet is a set of addresses.
+type AddressSet map[Address]struct{}
+
+// NewAddressSet returns a set holding the given addresses.
+func NewAddressSet(addrs ...Address) AddressSet {
+	set := make(AddressSet, len(addrs))
+	for _, a := range addrs {
+		set.Add(a)
+	}
+	return set
+}
+
+// Add adds a to the set.
+func (set AddressSet) Add(a Address) { set[a] = struct{}{} }
+
+// Remove removes a from the set.
+func (set AddressSet) Remove(a Address) { delete(set, a) }
+
+// Contains reports whether a is in the set.
+func (set AddressSet) Contains(a Address) bool {
+	_, ok := set[a]
+	return ok
+}
+
+// Len returns the number of addresses in the set.
+func (set AddressSet) Len() int { return len(set) }
+
+// ToSlice returns the addresses of the set in ascending order.
+func (set AddressSet) ToSlice() []Address {
+	addrs := make([]Address, 0, len(set))
+	for a := range set {
+		addrs = append(addrs, a)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i].Cmp(addrs[j]) < 0 })
+	return addrs
+}
+
 // IsHexAddress verifies whether a string can represent a valid hex-encoded
 // Ethereum address or not.
 func IsHexAddress(s string) bool {
@@ -184,6 +362,19 @@ func IsHexAddress(s string) bool {
 	return len(s) == 2*AddressLength && isHex(s)
 }
 
+// IsChecksumAddress verifies whether a string is a valid hex-encoded address
+// whose casing matches its EIP55 checksum. All-lowercase or all-uppercase
+// input is not considered checksummed.
+func IsChecksumAddress(s string) bool {
+	if !IsHexAddress(s) {
+		return false
+	}
+	if hasHexPrefix(s) {
+		s = s[2:]
+	}
+	return "0x"+s == HexToAddress(s).Hex()
+}
+
 // Bytes gets the string representation of the underlying address.
 func (a Address) Bytes() []byte { return a[:] }
 
@@ -193,14 +384,38 @@ func (a Address) Big() *big.Int { return new(big.Int).SetBytes(a[:]) }
 // Hash converts an address to a hash by left-padding it with zeros.
 func (a Address) Hash() Hash { return BytesToHash(a[:]) }
 
+// IsZero reports whether a is the zero address.
+func (a Address) IsZero() bool { return a == Address{} }
+
+// Cmp compares a and other as big-endian numbers and returns -1, 0 or +1.
+func (a Address) Cmp(other Address) int {
+	return bytes.Compare(a[:], other[:])
+}
+
+// Equal reports whether a and other are identical, in constant time. Use it
+// instead of == when either side is derived from secret material and the
+// comparison must not leak how many leading bytes matched.
+func (a Address) Equal(other Address) bool {
+	return subtle.ConstantTimeCompare(a[:], other[:]) == 1
+}
+
+// HasPrefix reports whether a begins with p.
+func (a Address) HasPrefix(p []byte) bool {
+	return bytes.HasPrefix(a[:], p)
+}
+
 // Hex returns an EIP55-compliant hex string representation of the address.
 func (a Address) Hex() string {
-	unchecksummed := hex.EncodeToString(a[:])
+	var buf [2 + 2*AddressLength]byte
+	copy(buf[:2], "0x")
+	result := buf[2:]
+	hex.Encode(result, a[:])
+
+	var hash [32]byte
 	sha := sha3.NewKeccak256()
-	sha.Write([]byte(unchecksummed))
-	hash := sha.Sum(nil)
+	sha.Write(result)
+	sha.Sum(hash[:0])
 
-	result := []byte(unchecksummed)
 	for i := 0; i < len(result); i++ {
 		hashByte := hash[i/2]
 		if i%2 == 0 {
@@ -212,7 +427,14 @@ func (a Address) Hex() string {
 			result[i] -= 32
 		}
 	}
-	return "0x" + string(result)
+	return string(buf[:])
+}
+
+// Short returns an abbreviated form of the checksummed address for console
+// output, keeping the first and last 4 hex digits.
+func (a Address) Short() string {
+	hex := a.Hex()
+	return hex[:6] + "…" + hex[len(hex)-4:]
 }
 
 // String implements fmt.Stringer.
@@ -235,11 +457,32 @@ func (a *Address) SetBytes(b []byte) {
 	copy(a[AddressLength-len(b):], b)
 }
 
+// SetString sets the address from its hex representation. Unlike
+// HexToAddress it requires exactly 40 hex digits, with an optional 0x prefix.
+// The checksum casing is not verified.
+func (a *Address) SetString(s string) error {
+	if !IsHexAddress(s) {
+		return fmt.Errorf("invalid address %q, want %d hex digits", s, 2*AddressLength)
+	}
+	if hasHexPrefix(s) {
+		s = s[2:]
+	}
+	a.SetBytes(Hex2Bytes(s))
+	return nil
+}
+
 // MarshalText returns the hex representation of a.
 func (a Address) MarshalText() ([]byte, error) {
 	return hexutil.Bytes(a[:]).MarshalText()
 }
 
+// AppendHex appends the 0x prefixed, non-checksummed hex encoding of a, as
+// produced by MarshalText, to dst. It does not allocate if dst has enough
+// capacity.
+func (a Address) AppendHex(dst []byte) []byte {
+	return appendHex(dst, a[:])
+}
+
 // UnmarshalText parses a hash in hex syntax.
 func (a *Address) UnmarshalText(input []byte) error {
 	return hexutil.UnmarshalFixedText("Address", input, a[:])
@@ -281,6 +524,30 @@ func (a UnprefixedAddress) MarshalText() ([]byte, error) {
 	return []byte(hex.EncodeToString(a[:])), nil
 }
 
+// UnmarshalJSON parses an address from a JSON string. The 0x prefix is
+// optional.
+func (a *UnprefixedAddress) UnmarshalJSON(input []byte) error {
+	return unmarshalUnprefixedJSON("UnprefixedAddress", input, a)
+}
+
+// MarshalJSON encodes the address as a JSON string of hex without 0x prefix.
+func (a UnprefixedAddress) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + hex.EncodeToString(a[:]) + `"`), nil
+}
+
+// unmarshalUnprefixedJSON decodes the JSON string input into v using its
+// UnmarshalText method. JSON null leaves v unchanged.
+func unmarshalUnprefixedJSON(typname string, input []byte, v encoding.TextUnmarshaler) error {
+	if string(input) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(input, &s); err != nil {
+		return fmt.Errorf("json: cannot unmarshal non-string into Go value of type %s", typname)
+	}
+	return v.UnmarshalText([]byte(s))
+}
+
 // MixedcaseAddress retains the original string, which may or may not be
 // correctly checksummed
 type MixedcaseAddress struct {
@@ -302,6 +569,21 @@ func NewMixedcaseAddressFromString(hexaddr string) (*MixedcaseAddress, error) {
 	return &MixedcaseAddress{addr: BytesToAddress(a), original: hexaddr}, nil
 }
 
+// ParseMixedcaseAddresses parses each of ss with NewMixedcaseAddressFromString.
+// The error names the index of the first invalid entry. Checksums are not
+// enforced, use ValidChecksum on the results.
+func ParseMixedcaseAddresses(ss []string) ([]*MixedcaseAddress, error) {
+	res := make([]*MixedcaseAddress, len(ss))
+	for i, s := range ss {
+		ma, err := NewMixedcaseAddressFromString(s)
+		if err != nil {
+			return nil, fmt.Errorf("address %d (%q): %v", i, s, err)
+		}
+		res[i] = ma
+	}
+	return res, nil
+}
+
 // UnmarshalJSON parses MixedcaseAddress
 func (ma *MixedcaseAddress) UnmarshalJSON(input []byte) error {
 	if err := hexutil.UnmarshalFixedJSON(addressT, input, ma.addr[:]); err != nil {
@@ -310,14 +592,27 @@ func (ma *MixedcaseAddress) UnmarshalJSON(input []byte) error {
 	return json.Unmarshal(input, &ma.original)
 }
 
-// MarshalJSON marshals the original value
+// MarshalJSON marshals the original value. A nil address marshals as null.
 func (ma *MixedcaseAddress) MarshalJSON() ([]byte, error) {
+	if ma == nil {
+		return []byte("null"), nil
+	}
 	if strings.HasPrefix(ma.original, "0x") || strings.HasPrefix(ma.original, "0X") {
 		return json.Marshal(fmt.Sprintf("0x%s", ma.original[2:]))
 	}
 	return json.Marshal(fmt.Sprintf("0x%s", ma.original))
 }
 
+// MarshalJSONChecksummed marshals the EIP55 checksummed form of the address,
+// regardless of the casing of the original value. A nil address marshals as
+// null.
+func (ma *MixedcaseAddress) MarshalJSONChecksummed() ([]byte, error) {
+	if ma == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(ma.addr.Hex())
+}
+
 // Address returns the address
 func (ma *MixedcaseAddress) Address() Address {
 	return ma.addr
@@ -325,12 +620,38 @@ func (ma *MixedcaseAddress) Address() Address {
 
 // String implements fmt.Stringer
 func (ma *MixedcaseAddress) String() string {
-	if ma.ValidChecksum() {
+	switch ma.ChecksumState() {
+	case ChecksumValid:
 		return fmt.Sprintf("%s [chksum ok]", ma.original)
+	case ChecksumAbsent:
+		return fmt.Sprintf("%s [no chksum]", ma.original)
 	}
 	return fmt.Sprintf("%s [chksum INVALID]", ma.original)
 }
 
+// Checksum states of a MixedcaseAddress.
+const (
+	ChecksumAbsent  = iota // input is all one case, so carries no checksum
+	ChecksumValid          // input matches the EIP55 checksum
+	ChecksumInvalid        // input is mixed case but does not match the checksum
+)
+
+// ChecksumState reports whether the original input carries a valid, an
+// invalid or no EIP55 checksum at all.
+func (ma *MixedcaseAddress) ChecksumState() int {
+	if ma.ValidChecksum() {
+		return ChecksumValid
+	}
+	hex := ma.original
+	if hasHexPrefix(hex) {
+		hex = hex[2:]
+	}
+	if hex == strings.ToLower(hex) || hex == strings.ToUpper(hex) {
+		return ChecksumAbsent
+	}
+	return ChecksumInvalid
+}
+
 // ValidChecksum returns true if the address has valid checksum
 func (ma *MixedcaseAddress) ValidChecksum() bool {
 	return ma.original == ma.addr.Hex()
@@ -340,3 +661,13 @@ func (ma *MixedcaseAddress) ValidChecksum() bool {
 func (ma *MixedcaseAddress) Original() string {
 	return ma.original
 }
+
+// appendHex appends the 0x prefixed hex encoding of b to dst.
+func appendHex(dst, b []byte) []byte {
+	const hextable = "0123456789abcdef"
+	dst = append(dst, "0x"...)
+	for _, c := range b {
+		dst = append(dst, hextable[c>>4], hextable[c&0xf])
+	}
+	return dst
+}
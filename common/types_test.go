@@ -17,12 +17,17 @@
 package common
 
 import (
+	"bytes"
 	"database/sql/driver"
+	"encoding/hex"
 	"encoding/json"
 	"math/big"
+	"math/rand"
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/FusionFoundation/efsn/crypto/sha3"
 )
 
 func TestBytesConversion(t *testing.T) {
@@ -145,13 +150,56 @@ func TestAddressHexChecksum(t *testing.T) {
 	}
 }
 
+// referenceAddressHex is the straightforward EIP55 encoding Address.Hex
+// must stay byte-identical to.
+func referenceAddressHex(a Address) string {
+	unchecksummed := hex.EncodeToString(a[:])
+	sha := sha3.NewKeccak256()
+	sha.Write([]byte(unchecksummed))
+	hash := sha.Sum(nil)
+
+	result := []byte(unchecksummed)
+	for i := 0; i < len(result); i++ {
+		hashByte := hash[i/2]
+		if i%2 == 0 {
+			hashByte = hashByte >> 4
+		} else {
+			hashByte &= 0xf
+		}
+		if result[i] > '9' && hashByte > 7 {
+			result[i] -= 32
+		}
+	}
+	return "0x" + string(result)
+}
+
+func TestAddressHexMatchesReference(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		var a Address
+		rnd.Read(a[:])
+		if have, want := a.Hex(), referenceAddressHex(a); have != want {
+			t.Fatalf("address %x: have %s, want %s", a[:], have, want)
+		}
+	}
+}
+
 func BenchmarkAddressHex(b *testing.B) {
 	testAddr := HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")
+	b.ReportAllocs()
 	for n := 0; n < b.N; n++ {
 		testAddr.Hex()
 	}
 }
 
+func BenchmarkAddressHexReference(b *testing.B) {
+	testAddr := HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		referenceAddressHex(testAddr)
+	}
+}
+
 func TestMixedcaseAccount_Address(t *testing.T) {
 
 	// https://github.com/ethereum/EIPs/blob/master/EIPS/eip-55.md
@@ -371,3 +419,592 @@ func TestAddress_Value(t *testing.T) {
 		})
 	}
 }
+
+func TestHashCmp(t *testing.T) {
+	tests := []struct {
+		a, b Hash
+		exp  int
+	}{
+		{Hash{}, Hash{}, 0},
+		{Hash{}, HexToHash("0x01"), -1},
+		{HexToHash("0x01"), Hash{}, 1},
+		{HexToHash("0x0100"), HexToHash("0xff"), 1},
+		{HexToHash("0xff"), HexToHash("0x0100"), -1},
+		{SystemAssetID, SystemAssetID, 0},
+	}
+	for i, test := range tests {
+		if res := test.a.Cmp(test.b); res != test.exp {
+			t.Errorf("test #%d: %x.Cmp(%x) = %d, want %d", i, test.a, test.b, res, test.exp)
+		}
+	}
+}
+
+func TestAddressCmp(t *testing.T) {
+	tests := []struct {
+		a, b Address
+		exp  int
+	}{
+		{Address{}, Address{}, 0},
+		{Address{}, HexToAddress("0x01"), -1},
+		{HexToAddress("0x01"), Address{}, 1},
+		{HexToAddress("0x0100"), HexToAddress("0xff"), 1},
+		{HexToAddress("0xff"), HexToAddress("0x0100"), -1},
+		{FSNCallAddress, FSNCallAddress, 0},
+	}
+	for i, test := range tests {
+		if res := test.a.Cmp(test.b); res != test.exp {
+			t.Errorf("test #%d: %x.Cmp(%x) = %d, want %d", i, test.a, test.b, res, test.exp)
+		}
+	}
+}
+
+func TestHashEqual(t *testing.T) {
+	hashes := []Hash{
+		{},
+		HexToHash("0x01"),
+		HexToHash("0x0100"),
+		HexToHash("0x0100000000000000000000000000000000000000000000000000000000000000"),
+		SystemAssetID,
+	}
+	for _, a := range hashes {
+		for _, b := range hashes {
+			if a.Equal(b) != (a == b) {
+				t.Errorf("%x.Equal(%x) = %v, want %v", a, b, a.Equal(b), a == b)
+			}
+		}
+	}
+}
+
+func TestAddressEqual(t *testing.T) {
+	addrs := []Address{
+		{},
+		HexToAddress("0x01"),
+		HexToAddress("0x0100"),
+		HexToAddress("0x0100000000000000000000000000000000000000"),
+		FSNCallAddress,
+	}
+	for _, a := range addrs {
+		for _, b := range addrs {
+			if a.Equal(b) != (a == b) {
+				t.Errorf("%x.Equal(%x) = %v, want %v", a, b, a.Equal(b), a == b)
+			}
+		}
+	}
+}
+
+func TestHashHasPrefix(t *testing.T) {
+	h := HexToHash("0xabcd000000000000000000000000000000000000000000000000000000001234")
+	tests := []struct {
+		p                    []byte
+		hasPrefix, hasSuffix bool
+	}{
+		{nil, true, true},
+		{[]byte{}, true, true},
+		{[]byte{0xab}, true, false},
+		{[]byte{0xab, 0xcd}, true, false},
+		{[]byte{0xab, 0xce}, false, false},
+		{[]byte{0x12, 0x34}, false, true},
+		{[]byte{0x34}, false, true},
+		{h[:], true, true},
+		{append(h.Bytes(), 0), false, false},
+	}
+	for _, test := range tests {
+		if have := h.HasPrefix(test.p); have != test.hasPrefix {
+			t.Errorf("HasPrefix(%x) = %v, want %v", test.p, have, test.hasPrefix)
+		}
+		if have := h.HasSuffix(test.p); have != test.hasSuffix {
+			t.Errorf("HasSuffix(%x) = %v, want %v", test.p, have, test.hasSuffix)
+		}
+	}
+}
+
+func TestAddressHasPrefix(t *testing.T) {
+	a := HexToAddress("0xabcd000000000000000000000000000000001234")
+	tests := []struct {
+		p    []byte
+		want bool
+	}{
+		{nil, true},
+		{[]byte{0xab, 0xcd}, true},
+		{[]byte{0x12, 0x34}, false},
+		{a[:], true},
+		{append(a.Bytes(), 0), false},
+		{make([]byte, 33), false},
+	}
+	for _, test := range tests {
+		if have := a.HasPrefix(test.p); have != test.want {
+			t.Errorf("HasPrefix(%x) = %v, want %v", test.p, have, test.want)
+		}
+	}
+}
+
+func TestIsZero(t *testing.T) {
+	if !(Hash{}).IsZero() {
+		t.Error("zero hash not reported as zero")
+	}
+	if !(Address{}).IsZero() {
+		t.Error("zero address not reported as zero")
+	}
+	for _, h := range []Hash{HexToHash("0x01"), HexToHash("0x0100"), SystemAssetID} {
+		if h.IsZero() {
+			t.Errorf("hash %x reported as zero", h)
+		}
+	}
+	for _, a := range []Address{HexToAddress("0x01"), HexToAddress("0x0100"), FSNCallAddress} {
+		if a.IsZero() {
+			t.Errorf("address %x reported as zero", a)
+		}
+	}
+}
+
+func TestHexToAddresses(t *testing.T) {
+	hexes := []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+	}
+	addrs, err := HexToAddresses(hexes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if back := AddressesToHex(addrs); !reflect.DeepEqual(back, hexes) {
+		t.Errorf("round trip mismatch: have %v, want %v", back, hexes)
+	}
+
+	invalid := []string{hexes[0], "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beae", hexes[2]}
+	if _, err := HexToAddresses(invalid); err == nil || !strings.Contains(err.Error(), "index 1") {
+		t.Errorf("expected error naming index 1, got %v", err)
+	}
+
+	addrs, err = HexToAddresses([]string{})
+	if err != nil || len(addrs) != 0 {
+		t.Errorf("empty input: have %v, %v", addrs, err)
+	}
+	if hexes := AddressesToHex(nil); len(hexes) != 0 {
+		t.Errorf("empty input: have %v", hexes)
+	}
+}
+
+func TestIsChecksumAddress(t *testing.T) {
+	tests := []struct {
+		str string
+		exp bool
+	}{
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", true},
+		{"5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", true},
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", false},
+		{"0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", false},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", false},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAe", false},
+		{"0x0000000000000000000000000000000000000000", true},
+	}
+	for _, test := range tests {
+		if result := IsChecksumAddress(test.str); result != test.exp {
+			t.Errorf("IsChecksumAddress(%s) == %v; expected %v", test.str, result, test.exp)
+		}
+	}
+}
+
+func TestParseMixedcaseAddresses(t *testing.T) {
+	valid := []string{
+		"0xAe967917c465db8578ca9024c205720b1a3651A9",
+		"0x1111111111111111111112222222222223333323",
+	}
+	res, err := ParseMixedcaseAddresses(valid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, ma := range res {
+		if ma.Original() != valid[i] || !ma.ValidChecksum() {
+			t.Errorf("entry %d: have %v", i, ma)
+		}
+	}
+
+	_, err = ParseMixedcaseAddresses([]string{valid[0], valid[1], "0x1234"})
+	if err == nil || !strings.Contains(err.Error(), "address 2 ") {
+		t.Errorf("have error %v, want error for index 2", err)
+	}
+
+	// a wrong checksum parses but is reported by ValidChecksum
+	res, err = ParseMixedcaseAddresses([]string{"0xae967917c465db8578ca9024c205720b1a3651A9"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res[0].ValidChecksum() {
+		t.Error("wrong checksum reported as valid")
+	}
+
+	if res, err := ParseMixedcaseAddresses(nil); err != nil || len(res) != 0 {
+		t.Errorf("ParseMixedcaseAddresses(nil) = %v, %v", res, err)
+	}
+}
+
+func TestMixedcaseAddressChecksumState(t *testing.T) {
+	tests := []struct {
+		input string
+		state int
+		str   string
+	}{
+		{"0xAe967917c465db8578ca9024c205720b1a3651A9", ChecksumValid, "[chksum ok]"},
+		{"0xae967917c465db8578ca9024c205720b1a3651a9", ChecksumAbsent, "[no chksum]"},
+		{"0xAE967917C465DB8578CA9024C205720B1A3651A9", ChecksumAbsent, "[no chksum]"},
+		{"0xae967917c465db8578ca9024c205720b1a3651A9", ChecksumInvalid, "[chksum INVALID]"},
+	}
+	for _, test := range tests {
+		ma, err := NewMixedcaseAddressFromString(test.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state := ma.ChecksumState(); state != test.state {
+			t.Errorf("%s: checksum state %d, want %d", test.input, state, test.state)
+		}
+		if str := ma.String(); str != test.input+" "+test.str {
+			t.Errorf("%s: String() = %q", test.input, str)
+		}
+	}
+}
+
+func TestHashSetString(t *testing.T) {
+	valid := "0x" + strings.Repeat("0a", 32)
+	tests := []struct {
+		str string
+		ok  bool
+	}{
+		{valid, true},
+		{valid[2:], true},
+		{"0X" + strings.Repeat("AB", 32), true},
+		{"0x01", false},
+		{valid[:65], false},
+		{valid + "00", false},
+		{"0x" + strings.Repeat("0g", 32), false},
+		{"", false},
+	}
+	for _, test := range tests {
+		var h Hash
+		err := h.SetString(test.str)
+		if test.ok && err != nil {
+			t.Errorf("SetString(%q): unexpected error: %v", test.str, err)
+		}
+		if !test.ok && err == nil {
+			t.Errorf("SetString(%q): expected error", test.str)
+		}
+		if test.ok && h != HexToHash(test.str) {
+			t.Errorf("SetString(%q) = %x", test.str, h)
+		}
+	}
+}
+
+func TestAddressSetString(t *testing.T) {
+	tests := []struct {
+		str string
+		ok  bool
+	}{
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", true},
+		{"5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", true},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", true},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", true},
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beae", false},
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed00", false},
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaeg", false},
+		{"0x01", false},
+	}
+	for _, test := range tests {
+		var a Address
+		err := a.SetString(test.str)
+		if test.ok && err != nil {
+			t.Errorf("SetString(%q): unexpected error: %v", test.str, err)
+		}
+		if !test.ok && err == nil {
+			t.Errorf("SetString(%q): expected error", test.str)
+		}
+		if test.ok && a != HexToAddress(test.str) {
+			t.Errorf("SetString(%q) = %x", test.str, a)
+		}
+	}
+}
+
+func TestPubkeyBytesToAddress(t *testing.T) {
+	pub := FromHex("04ca634cae0d49acb401d8a4c6b6fe8c55b70d115bf400769cc1400f3258cd31387574077f301b421bc84df7266c44e9e6d569fc56be00812904767bf5ccd1fc7f")
+	want := HexToAddress("0x71562b71999873DB5b286dF957af199Ec94617F7")
+	for _, key := range [][]byte{pub, pub[1:]} {
+		addr, err := PubkeyBytesToAddress(key)
+		if err != nil {
+			t.Fatalf("unexpected error for %d byte key: %v", len(key), err)
+		}
+		if addr != want {
+			t.Errorf("%d byte key: address mismatch: have %x, want %x", len(key), addr, want)
+		}
+	}
+	for _, key := range [][]byte{nil, pub[:33], pub[1:64], append([]byte{0x02}, pub[1:]...), append(pub, 0)} {
+		if _, err := PubkeyBytesToAddress(key); err == nil {
+			t.Errorf("expected error for %d byte key", len(key))
+		}
+	}
+}
+
+func TestAppendHex(t *testing.T) {
+	h := HexToHash("0x7f0cfaf4bd8d13e3d0f5da1d4e1c25b0e0a7dc0aeb34e1d23a7e4d6a5ad4ee80")
+	a := HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")
+	htext, _ := h.MarshalText()
+	atext, _ := a.MarshalText()
+	if have := h.AppendHex(nil); string(have) != string(htext) {
+		t.Errorf("Hash.AppendHex = %s, want %s", have, htext)
+	}
+	if have := a.AppendHex(nil); string(have) != string(atext) {
+		t.Errorf("Address.AppendHex = %s, want %s", have, atext)
+	}
+	if have := a.AppendHex([]byte("to:")); string(have) != "to:"+string(atext) {
+		t.Errorf("Address.AppendHex with prefix = %s", have)
+	}
+
+	buf := make([]byte, 0, 128)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = h.AppendHex(buf[:0])
+		buf = a.AppendHex(buf)
+	})
+	if allocs != 0 {
+		t.Errorf("AppendHex allocated %v times, want 0", allocs)
+	}
+}
+
+func BenchmarkHashAppendHex(b *testing.B) {
+	h := HexToHash("0x7f0cfaf4bd8d13e3d0f5da1d4e1c25b0e0a7dc0aeb34e1d23a7e4d6a5ad4ee80")
+	buf := make([]byte, 0, 2+2*HashLength)
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		buf = h.AppendHex(buf[:0])
+	}
+}
+
+func BenchmarkHashMarshalText(b *testing.B) {
+	h := HexToHash("0x7f0cfaf4bd8d13e3d0f5da1d4e1c25b0e0a7dc0aeb34e1d23a7e4d6a5ad4ee80")
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		h.MarshalText()
+	}
+}
+
+func TestUnprefixedJSON(t *testing.T) {
+	type unprefixed struct {
+		Hash UnprefixedHash
+		Addr UnprefixedAddress
+	}
+	in := unprefixed{
+		Hash: UnprefixedHash(HexToHash("0x7f0cfaf4bd8d13e3d0f5da1d4e1c25b0e0a7dc0aeb34e1d23a7e4d6a5ad4ee80")),
+		Addr: UnprefixedAddress(HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")),
+	}
+	enc, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"Hash":"7f0cfaf4bd8d13e3d0f5da1d4e1c25b0e0a7dc0aeb34e1d23a7e4d6a5ad4ee80","Addr":"5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"}`
+	if string(enc) != want {
+		t.Errorf("marshal mismatch:\nhave %s\nwant %s", enc, want)
+	}
+	var out unprefixed
+	if err := json.Unmarshal(enc, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out != in {
+		t.Errorf("round trip mismatch: have %+v, want %+v", out, in)
+	}
+
+	// the 0x prefix is accepted on input
+	if err := json.Unmarshal([]byte(`{"Addr":"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"}`), &out); err != nil || out.Addr != in.Addr {
+		t.Errorf("prefixed address: have %x, err %v", out.Addr, err)
+	}
+	for _, input := range []string{`{"Addr":5}`, `{"Addr":"5aae"}`, `{"Hash":"0xzz"}`} {
+		if err := json.Unmarshal([]byte(input), &out); err == nil {
+			t.Errorf("%s: expected error", input)
+		}
+	}
+	if err := json.Unmarshal([]byte(`{"Hash":null}`), &out); err != nil {
+		t.Errorf("null hash: unexpected error: %v", err)
+	}
+}
+
+func TestAddressShort(t *testing.T) {
+	addr := HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")
+	if have, want := addr.Short(), "0x5aAe…eAed"; have != want {
+		t.Errorf("Short() = %s, want %s", have, want)
+	}
+	corpus := []string{
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		"0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359",
+		"0xdbf03b407c01e7cd3cbea99509d93f8dddc8c6fb",
+		"0xd1220a0cf47c7b9be7a2e6ba89f429762e7b9adb",
+		"0x0000000000000000000000000000000000000000",
+		"0xffffffffffffffffffffffffffffffffffffffff",
+	}
+	seen := make(map[string]string)
+	for _, s := range corpus {
+		short := HexToAddress(s).Short()
+		if len(short) != len("0x1234…abcd") {
+			t.Errorf("%s: unexpected short form %q", s, short)
+		}
+		if prev, ok := seen[short]; ok {
+			t.Errorf("%s and %s share short form %s", prev, s, short)
+		}
+		seen[short] = s
+	}
+}
+
+func TestNewHashRand(t *testing.T) {
+	seed := HexToHash("0x7f0cfaf4bd8d13e3d0f5da1d4e1c25b0e0a7dc0aeb34e1d23a7e4d6a5ad4ee80")
+	r1, r2 := NewHashRand(seed), NewHashRand(seed)
+	for i := 0; i < 100; i++ {
+		if a, b := r1.Uint64(), r2.Uint64(); a != b {
+			t.Fatalf("draw %d: same seed diverged: %d != %d", i, a, b)
+		}
+	}
+
+	other := seed
+	other[HashLength-1]++
+	r1, r3 := NewHashRand(seed), NewHashRand(other)
+	same := true
+	for i := 0; i < 10; i++ {
+		if r1.Uint64() != r3.Uint64() {
+			same = false
+		}
+	}
+	if same {
+		t.Error("different seeds produced identical sequences")
+	}
+}
+
+func TestMixedcaseAddressMarshalJSONChecksummed(t *testing.T) {
+	var ma MixedcaseAddress
+	if err := json.Unmarshal([]byte(`"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"`), &ma); err != nil {
+		t.Fatal(err)
+	}
+	orig, err := ma.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"`; string(orig) != want {
+		t.Errorf("MarshalJSON = %s, want %s", orig, want)
+	}
+	chksum, err := ma.MarshalJSONChecksummed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"`; string(chksum) != want {
+		t.Errorf("MarshalJSONChecksummed = %s, want %s", chksum, want)
+	}
+}
+
+func TestDedupHashes(t *testing.T) {
+	h1, h2, h3 := HexToHash("0x01"), HexToHash("0x02"), HexToHash("0x03")
+	tests := []struct {
+		in, want []Hash
+	}{
+		{[]Hash{h1, h2, h1, h3, h2, h1}, []Hash{h1, h2, h3}},
+		{[]Hash{h3, h1, h2}, []Hash{h3, h1, h2}},
+		{[]Hash{}, []Hash{}},
+		{nil, []Hash{}},
+	}
+	for i, test := range tests {
+		if have := DedupHashes(test.in); !reflect.DeepEqual(have, test.want) {
+			t.Errorf("test %d: have %v, want %v", i, have, test.want)
+		}
+	}
+}
+
+func TestDedupAddresses(t *testing.T) {
+	a1, a2, a3 := HexToAddress("0x01"), HexToAddress("0x02"), HexToAddress("0x03")
+	tests := []struct {
+		in, want []Address
+	}{
+		{[]Address{a1, a2, a1, a3, a2, a1}, []Address{a1, a2, a3}},
+		{[]Address{a3, a1, a2}, []Address{a3, a1, a2}},
+		{[]Address{}, []Address{}},
+		{nil, []Address{}},
+	}
+	for i, test := range tests {
+		if have := DedupAddresses(test.in); !reflect.DeepEqual(have, test.want) {
+			t.Errorf("test %d: have %v, want %v", i, have, test.want)
+		}
+	}
+	in := []Address{a1, a2}
+	DedupAddresses(in)[0] = a3
+	if in[0] != a1 {
+		t.Error("DedupAddresses aliases its input")
+	}
+}
+
+func TestAddressSet(t *testing.T) {
+	a1, a2, a3 := HexToAddress("0x01"), HexToAddress("0x02"), HexToAddress("0x03")
+	set := NewAddressSet(a3, a1, a3, a2, a1)
+	if set.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", set.Len())
+	}
+	for _, a := range []Address{a1, a2, a3} {
+		if !set.Contains(a) {
+			t.Errorf("set does not contain %x", a)
+		}
+	}
+	if want := []Address{a1, a2, a3}; !reflect.DeepEqual(set.ToSlice(), want) {
+		t.Errorf("ToSlice() = %v, want %v", set.ToSlice(), want)
+	}
+
+	set.Remove(a2)
+	set.Remove(HexToAddress("0x04"))
+	if set.Contains(a2) || set.Len() != 2 {
+		t.Errorf("remove failed: %v", set.ToSlice())
+	}
+	set.Add(a2)
+	if !set.Contains(a2) {
+		t.Error("re-added address missing")
+	}
+	if NewAddressSet().Len() != 0 || len(NewAddressSet().ToSlice()) != 0 {
+		t.Error("empty set not empty")
+	}
+}
+
+func TestMixedcaseAddressNilMarshalJSON(t *testing.T) {
+	type optional struct {
+		From *MixedcaseAddress
+		To   *MixedcaseAddress `json:",omitempty"`
+		Via  *MixedcaseAddress
+	}
+	var via MixedcaseAddress
+	if err := json.Unmarshal([]byte(`"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"`), &via); err != nil {
+		t.Fatal(err)
+	}
+	enc, err := json.Marshal(optional{Via: &via})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"From":null,"Via":"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"}`; string(enc) != want {
+		t.Errorf("have %s, want %s", enc, want)
+	}
+
+	var ma *MixedcaseAddress
+	for name, marshal := range map[string]func() ([]byte, error){
+		"MarshalJSON":            ma.MarshalJSON,
+		"MarshalJSONChecksummed": ma.MarshalJSONChecksummed,
+	} {
+		if enc, err := marshal(); err != nil || string(enc) != "null" {
+			t.Errorf("nil %s = %s, %v", name, enc, err)
+		}
+	}
+}
+
+func TestArrayConversion(t *testing.T) {
+	h := HexToHash("0x7f0cfaf4bd8d13e3d0f5da1d4e1c25b0e0a7dc0aeb34e1d23a7e4d6a5ad4ee80")
+	var harr [32]byte = h.Array()
+	if HashFromArray(harr) != h || !bytes.Equal(harr[:], h[:]) {
+		t.Errorf("hash round trip mismatch: %x", harr)
+	}
+	a := HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")
+	var aarr [20]byte = a.Array()
+	if AddressFromArray(aarr) != a || !bytes.Equal(aarr[:], a[:]) {
+		t.Errorf("address round trip mismatch: %x", aarr)
+	}
+	// the arrays are copies
+	harr[0]++
+	aarr[0]++
+	if h[0] == harr[0] || a[0] == aarr[0] {
+		t.Error("array aliases its source")
+	}
+}
@@ -117,7 +117,7 @@ func CanTransferTimeLock(db vm.StateDB, addr common.Address, p *common.TransferT
 
 	assetBalance := db.GetBalance(p.AssetID, addr)
 	if p.GasValue != nil && p.GasValue.Sign() > 0 {
-		if p.AssetID == common.SystemAssetID {
+		if p.AssetID.IsSystemAsset() {
 			if assetBalance.Cmp(p.GasValue) < 0 {
 				return false
 			}
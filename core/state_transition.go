@@ -17,12 +17,10 @@
 package core
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
 	"math/big"
-	"reflect"
 	"time"
 
 	"github.com/FusionFoundation/efsn/common"
@@ -316,9 +314,7 @@ func (st *StateTransition) handleFsnCall(param *common.FSNCallParam) error {
 			st.addLog(common.GenAssetFunc, genAssetParam, common.NewKeyValue("Error", err.Error()))
 			return err
 		}
-		asset := genAssetParam.ToAsset()
-		asset.ID = st.msg.AsTransaction().Hash()
-		asset.Owner = st.msg.From()
+		asset := genAssetParam.ToAssetWithOwner(st.msg.AsTransaction().Hash(), st.msg.From())
 		if err := st.state.GenAsset(asset); err != nil {
 			st.addLog(common.GenAssetFunc, genAssetParam, common.NewKeyValue("Error", "unable to gen asset"))
 			return err
@@ -555,14 +551,14 @@ func (st *StateTransition) handleFsnCall(param *common.FSNCallParam) error {
 			return fmt.Errorf("asset not found")
 		}
 
-		if !asset.CanChange {
-			st.addLog(common.AssetValueChangeFunc, assetValueChangeParamEx, common.NewKeyValue("Error", "asset can't inc or dec"))
-			return fmt.Errorf("asset can't inc or dec")
+		if err := asset.CheckValueChangeAllowed(); err != nil {
+			st.addLog(common.AssetValueChangeFunc, assetValueChangeParamEx, common.NewKeyValue("Error", err.Error()))
+			return err
 		}
 
-		if asset.Owner != st.msg.From() {
-			st.addLog(common.AssetValueChangeFunc, assetValueChangeParamEx, common.NewKeyValue("Error", "can only be changed by owner"))
-			return fmt.Errorf("can only be changed by owner")
+		if err := asset.CheckOwner(st.msg.From()); err != nil {
+			st.addLog(common.AssetValueChangeFunc, assetValueChangeParamEx, common.NewKeyValue("Error", err.Error()))
+			return err
 		}
 
 		if asset.Owner != assetValueChangeParamEx.To && !assetValueChangeParamEx.IsInc {
@@ -647,23 +643,8 @@ func (st *StateTransition) handleFsnCall(param *common.FSNCallParam) error {
 				}
 			}
 		}
-		swap := common.Swap{
-			ID:            swapId,
-			Owner:         st.msg.From(),
-			FromAssetID:   makeSwapParam.FromAssetID,
-			FromStartTime: makeSwapParam.FromStartTime,
-			FromEndTime:   makeSwapParam.FromEndTime,
-			MinFromAmount: makeSwapParam.MinFromAmount,
-			ToAssetID:     makeSwapParam.ToAssetID,
-			ToStartTime:   makeSwapParam.ToStartTime,
-			ToEndTime:     makeSwapParam.ToEndTime,
-			MinToAmount:   makeSwapParam.MinToAmount,
-			SwapSize:      makeSwapParam.SwapSize,
-			Targes:        makeSwapParam.Targes,
-			Time:          makeSwapParam.Time, // this will mean the block time
-			Description:   makeSwapParam.Description,
-			Notation:      notation,
-		}
+		swap := makeSwapParam.ToSwap(swapId, st.msg.From())
+		swap.Notation = notation
 
 		if makeSwapParam.FromAssetID == common.OwnerUSANAssetID {
 			if err := st.state.AddSwap(swap); err != nil {
@@ -734,7 +715,7 @@ func (st *StateTransition) handleFsnCall(param *common.FSNCallParam) error {
 			return fmt.Errorf("Must be swap onwer can recall")
 		}
 
-		if err := recallSwapParam.Check(height, &swap); err != nil {
+		if err := recallSwapParam.Check(height, &swap, st.msg.From()); err != nil {
 			st.addLog(common.RecallSwapFunc, recallSwapParam, common.NewKeyValue("Error", err.Error()))
 			return err
 		}
@@ -777,18 +758,11 @@ func (st *StateTransition) handleFsnCall(param *common.FSNCallParam) error {
 			return fmt.Errorf("Swap not found")
 		}
 
-		if err := takeSwapParam.Check(height, &swap, timestamp); err != nil {
+		if err := takeSwapParam.CheckWithTaker(height, &swap, timestamp, st.msg.From()); err != nil {
 			st.addLog(common.TakeSwapFunc, takeSwapParam, common.NewKeyValue("Error", err.Error()))
 			return err
 		}
 
-		if common.IsPrivateSwapCheckingEnabled(height) {
-			if err := common.CheckSwapTargets(swap.Targes, st.msg.From()); err != nil {
-				st.addLog(common.TakeSwapFunc, takeSwapParam, common.NewKeyValue("Error", err.Error()))
-				return err
-			}
-		}
-
 		var usanSwap bool
 		if swap.FromAssetID == common.OwnerUSANAssetID {
 			notation := st.state.GetNotation(swap.Owner)
@@ -1350,26 +1324,7 @@ func (st *StateTransition) handleFsnCall(param *common.FSNCallParam) error {
 }
 
 func (st *StateTransition) addLog(typ common.FSNCallFunc, value interface{}, keyValues ...*common.KeyValue) {
-
-	t := reflect.TypeOf(value)
-	v := reflect.ValueOf(value)
-
-	maps := make(map[string]interface{})
-	if t.Kind() == reflect.Struct {
-		for i := 0; i < t.NumField(); i++ {
-			if v.Field(i).CanInterface() {
-				maps[t.Field(i).Name] = v.Field(i).Interface()
-			}
-		}
-	} else {
-		maps["Base"] = value
-	}
-
-	for i := 0; i < len(keyValues); i++ {
-		maps[keyValues[i].Key] = keyValues[i].Value
-	}
-
-	data, _ := json.Marshal(maps)
+	data := common.FSNCallLogData(value, keyValues...)
 
 	topic := common.Hash{}
 	topic[common.HashLength-1] = (uint8)(typ)
@@ -1456,7 +1456,7 @@ func (pool *TxPool) validateFsnCallTx(tx *types.Transaction) error {
 		if err := sendAssetParam.Check(height); err != nil {
 			return err
 		}
-		if sendAssetParam.AssetID == common.SystemAssetID {
+		if sendAssetParam.AssetID.IsSystemAsset() {
 			fsnValue = sendAssetParam.Value
 		} else if state.GetBalance(sendAssetParam.AssetID, from).Cmp(sendAssetParam.Value) < 0 {
 			return fmt.Errorf("not enough asset")
@@ -1493,7 +1493,7 @@ func (pool *TxPool) validateFsnCallTx(tx *types.Transaction) error {
 		}
 		switch timeLockParam.Type {
 		case common.AssetToTimeLock:
-			if timeLockParam.AssetID == common.SystemAssetID {
+			if timeLockParam.AssetID.IsSystemAsset() {
 				fsnValue = timeLockParam.Value
 			} else if state.GetBalance(timeLockParam.AssetID, from).Cmp(timeLockParam.Value) < 0 {
 				return fmt.Errorf("AssetToTimeLock: not enough asset")
@@ -1557,12 +1557,12 @@ func (pool *TxPool) validateFsnCallTx(tx *types.Transaction) error {
 			return fmt.Errorf("asset not found")
 		}
 
-		if !asset.CanChange {
-			return fmt.Errorf("asset can't inc or dec")
+		if err := asset.CheckValueChangeAllowed(); err != nil {
+			return err
 		}
 
-		if asset.Owner != from {
-			return fmt.Errorf("can only be changed by owner")
+		if err := asset.CheckOwner(from); err != nil {
+			return err
 		}
 
 		if asset.Owner != assetValueChangeParamEx.To && !assetValueChangeParamEx.IsInc {
@@ -1607,7 +1607,7 @@ func (pool *TxPool) validateFsnCallTx(tx *types.Transaction) error {
 			useAsset := start == common.TimeLockNow && end == common.TimeLockForever
 
 			if useAsset == true {
-				if makeSwapParam.FromAssetID == common.SystemAssetID {
+				if makeSwapParam.FromAssetID.IsSystemAsset() {
 					fsnValue = total
 				} else if state.GetBalance(makeSwapParam.FromAssetID, from).Cmp(total) < 0 {
 					return fmt.Errorf("not enough from asset")
@@ -1629,7 +1629,7 @@ func (pool *TxPool) validateFsnCallTx(tx *types.Transaction) error {
 						return fmt.Errorf("not enough time lock balance")
 					}
 
-					if makeSwapParam.FromAssetID == common.SystemAssetID {
+					if makeSwapParam.FromAssetID.IsSystemAsset() {
 						fsnValue = total
 					} else if state.GetBalance(makeSwapParam.FromAssetID, from).Cmp(total) < 0 {
 						return fmt.Errorf("not enough time lock or asset balance")
@@ -1651,7 +1651,7 @@ func (pool *TxPool) validateFsnCallTx(tx *types.Transaction) error {
 			return fmt.Errorf("Must be swap onwer can recall")
 		}
 
-		if err := recallSwapParam.Check(height, &swap); err != nil {
+		if err := recallSwapParam.Check(height, &swap, from); err != nil {
 			return err
 		}
 
@@ -1685,7 +1685,7 @@ func (pool *TxPool) validateFsnCallTx(tx *types.Transaction) error {
 		toUseAsset := toStart == common.TimeLockNow && toEnd == common.TimeLockForever
 
 		if toUseAsset == true {
-			if swap.ToAssetID == common.SystemAssetID {
+			if swap.ToAssetID.IsSystemAsset() {
 				fsnValue = toTotal
 			} else if state.GetBalance(swap.ToAssetID, from).Cmp(toTotal) < 0 {
 				return fmt.Errorf("not enough from asset")
@@ -1707,7 +1707,7 @@ func (pool *TxPool) validateFsnCallTx(tx *types.Transaction) error {
 					return fmt.Errorf("not enough time lock balance")
 				}
 
-				if swap.ToAssetID == common.SystemAssetID {
+				if swap.ToAssetID.IsSystemAsset() {
 					fsnValue = toTotal
 				} else if state.GetBalance(swap.ToAssetID, from).Cmp(toTotal) < 0 {
 					return fmt.Errorf("not enough time lock or asset balance")
@@ -1795,7 +1795,7 @@ func (pool *TxPool) validateFsnCallTx(tx *types.Transaction) error {
 					return fmt.Errorf("not enough from asset")
 				}
 				balance.Sub(balance, total[i])
-				if makeSwapParam.FromAssetID[i] == common.SystemAssetID {
+				if makeSwapParam.FromAssetID[i].IsSystemAsset() {
 					fsnValue.Add(fsnValue, total[i])
 				}
 			} else {
@@ -1805,7 +1805,7 @@ func (pool *TxPool) validateFsnCallTx(tx *types.Transaction) error {
 					}
 
 					balance.Sub(balance, total[i])
-					if makeSwapParam.FromAssetID[i] == common.SystemAssetID {
+					if makeSwapParam.FromAssetID[i].IsSystemAsset() {
 						fsnValue.Add(fsnValue, total[i])
 					}
 					totalValue := common.NewTimeLock(&common.TimeLockItem{
@@ -1877,7 +1877,7 @@ func (pool *TxPool) validateFsnCallTx(tx *types.Transaction) error {
 					return fmt.Errorf("not enough from asset")
 				}
 				balance.Sub(balance, toTotal[i])
-				if swap.ToAssetID[i] == common.SystemAssetID {
+				if swap.ToAssetID[i].IsSystemAsset() {
 					fsnValue.Add(fsnValue, toTotal[i])
 				}
 			} else {
@@ -1890,7 +1890,7 @@ func (pool *TxPool) validateFsnCallTx(tx *types.Transaction) error {
 					}
 
 					balance.Sub(balance, toTotal[i])
-					if swap.ToAssetID[i] == common.SystemAssetID {
+					if swap.ToAssetID[i].IsSystemAsset() {
 						fsnValue.Add(fsnValue, toTotal[i])
 					}
 					totalValue := common.NewTimeLock(&common.TimeLockItem{
@@ -938,12 +938,12 @@ func (s *PublicFusionAPI) BuildAssetValueChangeSendTxArgs(ctx context.Context, a
 		return nil, fmt.Errorf("asset not found")
 	}
 
-	if !asset.CanChange {
-		return nil, fmt.Errorf("asset can't inc or dec")
+	if err := asset.CheckValueChangeAllowed(); err != nil {
+		return nil, err
 	}
 
-	if asset.Owner != args.From {
-		return nil, fmt.Errorf("can only be changed by onwer")
+	if err := asset.CheckOwner(args.From); err != nil {
+		return nil, err
 	}
 
 	if asset.Owner != args.To && !args.IsInc {
@@ -1025,14 +1025,14 @@ func (s *PublicFusionAPI) BuildRecallSwapSendTxArgs(ctx context.Context, args co
 		return nil, err
 	}
 
-	if err := args.ToParam().Check(common.BigMaxUint64, &swap); err != nil {
-		return nil, err
-	}
-
 	if swap.Owner != args.From {
 		return nil, fmt.Errorf("Must be swap onwer can recall")
 	}
 
+	if err := args.ToParam().Check(common.BigMaxUint64, &swap, args.From); err != nil {
+		return nil, err
+	}
+
 	funcData, err := args.ToData()
 	if err != nil {
 		return nil, err